@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/mediafetch"
+)
+
+// Built-in tool names, exported so integrators can reference them when
+// building a per-request allowlist without retyping string literals.
+const (
+	ShellToolName     = "Shell"
+	FileReadToolName  = "FileRead"
+	FileWriteToolName = "FileWrite"
+	HTTPFetchToolName = "HTTPFetch"
+)
+
+// ShellTool executes a shell command via `sh -c` and returns its combined
+// output. It is disabled by default; callers must list it in Options.Allowed.
+type ShellTool struct{}
+
+func (ShellTool) Name() string        { return ShellToolName }
+func (ShellTool) Description() string { return "Executes a shell command and returns its output." }
+func (ShellTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"command":{"type":"string","description":"The command to execute"}},"required":["command"]}`)
+}
+
+func (ShellTool) Execute(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+	command := gjson.GetBytes(args, "command").String()
+	if command == "" {
+		return nil, fmt.Errorf("agent: Shell requires a non-empty \"command\"")
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("agent: Shell command failed: %w", err)
+	}
+	return resultJSON(string(output)), nil
+}
+
+// FileReadTool reads a file from the local filesystem.
+type FileReadTool struct{}
+
+func (FileReadTool) Name() string        { return FileReadToolName }
+func (FileReadTool) Description() string { return "Reads a file from the local filesystem." }
+func (FileReadTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"path":{"type":"string","description":"The path to the file to read"}},"required":["path"]}`)
+}
+
+func (FileReadTool) Execute(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+	path := gjson.GetBytes(args, "path").String()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("agent: FileRead failed: %w", err)
+	}
+	return resultJSON(string(data)), nil
+}
+
+// FileWriteTool writes content to a file on the local filesystem,
+// overwriting any existing content.
+type FileWriteTool struct{}
+
+func (FileWriteTool) Name() string        { return FileWriteToolName }
+func (FileWriteTool) Description() string { return "Writes content to a file on the local filesystem." }
+func (FileWriteTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"},"content":{"type":"string"}},"required":["path","content"]}`)
+}
+
+func (FileWriteTool) Execute(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+	path := gjson.GetBytes(args, "path").String()
+	content := gjson.GetBytes(args, "content").String()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return nil, fmt.Errorf("agent: FileWrite failed: %w", err)
+	}
+	return resultJSON("ok"), nil
+}
+
+// HTTPFetchTool performs a GET request and returns the response body. Its
+// entire purpose is following a model-supplied URL, so it fetches through
+// mediafetch.Fetch — the same SSRF-guarded (loopback/private/link-local
+// rejected at dial time), timeout-bounded, size-capped client the media-block
+// translators use — rather than an unguarded http.Client.
+type HTTPFetchTool struct{}
+
+func (HTTPFetchTool) Name() string        { return HTTPFetchToolName }
+func (HTTPFetchTool) Description() string { return "Fetches a URL over HTTP GET and returns the body." }
+func (HTTPFetchTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"url":{"type":"string"}},"required":["url"]}`)
+}
+
+func (HTTPFetchTool) Execute(ctx context.Context, args json.RawMessage) (json.RawMessage, error) {
+	url := gjson.GetBytes(args, "url").String()
+
+	_, data, err := mediafetch.Fetch(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("agent: HTTPFetch failed: %w", err)
+	}
+	return resultJSON(string(data)), nil
+}
+
+func resultJSON(content string) json.RawMessage {
+	encoded, _ := json.Marshal(map[string]string{"content": content})
+	return encoded
+}
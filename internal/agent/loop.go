@@ -0,0 +1,240 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ErrMaxStepsExceeded is returned when the loop hits Options.MaxSteps without
+// the upstream model producing a terminal text turn.
+var ErrMaxStepsExceeded = errors.New("agent: max steps exceeded")
+
+// ErrToolNotAllowed is reported back to the model as a functionResponse
+// error (and carried in the tool_result Event) when the model calls a tool
+// that isn't registered or isn't on the per-request allowlist; RunToolLoop
+// itself does not return it.
+var ErrToolNotAllowed = errors.New("agent: tool not allowed")
+
+// EventKind identifies the kind of intermediate event emitted while the loop
+// runs, so callers can stream them to the client over SSE.
+type EventKind string
+
+const (
+	EventToolUse    EventKind = "tool_use"
+	EventToolResult EventKind = "tool_result"
+)
+
+// Event is an intermediate tool_use/tool_result notification emitted while
+// the loop is running, ahead of the final response.
+type Event struct {
+	Kind EventKind
+	ID   string
+	Name string
+	Args json.RawMessage
+}
+
+// Upstream sends a translated Antigravity request and returns the raw
+// response body for a single (non-streaming) turn.
+type Upstream interface {
+	Send(ctx context.Context, antigravityRequest []byte) ([]byte, error)
+}
+
+// ConfirmFunc is consulted before executing a tool call; returning false
+// skips execution and reports a functionResponse error back to the model
+// instead, letting integrators prompt a human in the loop.
+type ConfirmFunc func(ctx context.Context, toolName string, args []byte) bool
+
+// Options configures a RunToolLoop invocation.
+type Options struct {
+	MaxSteps int
+	Confirm  ConfirmFunc
+	// Allowed lists the tool names this call may execute from toolbox. A
+	// functionCall for a registered-but-not-Allowed tool is reported back to
+	// the model as a functionResponse error instead of executed. Scoping the
+	// allowlist to Options (rather than mutating it onto a shared Toolbox)
+	// lets one Toolbox be reused, read-only, by concurrent requests that
+	// each permit a different set of tools.
+	Allowed []string
+	// OnEvent, if set, is called from the step that produced each
+	// tool_use/tool_result pair, before the next upstream call is made. Calls
+	// for parallel tool calls within one turn are serialized (never
+	// concurrent with each other), and each tool's tool_use and tool_result
+	// are delivered back to back once that tool has run, never interleaved
+	// with another tool's events, so an HTTP handler can safely write and
+	// flush an http.ResponseWriter directly from this callback, e.g. to
+	// stream Anthropic content_block_start/stop frames around a tool_use
+	// block — none of this package's built-in tools are safe to expose with
+	// a blanket allowlist, though, so that wiring also needs a deliberately
+	// chosen Allowed set before it's exposed on a real endpoint.
+	OnEvent func(Event)
+}
+
+func (o Options) allowedSet() map[string]bool {
+	allowed := make(map[string]bool, len(o.Allowed))
+	for _, name := range o.Allowed {
+		allowed[name] = true
+	}
+	return allowed
+}
+
+// RunToolLoop repeatedly sends req to upstream, executing any functionCall
+// parts the response contains via toolbox and appending their results as
+// functionResponse parts, until the upstream returns a turn with no
+// functionCall parts or Options.MaxSteps is reached.
+//
+// Parallel tool calls within a single model turn are executed concurrently,
+// mirroring how a single assistant turn may contain multiple tool_use blocks.
+func RunToolLoop(ctx context.Context, req []byte, toolbox *Toolbox, upstream Upstream, opts Options) ([]byte, error) {
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = 10
+	}
+
+	current := req
+	for step := 0; step < opts.MaxSteps; step++ {
+		resp, err := upstream.Send(ctx, current)
+		if err != nil {
+			return nil, fmt.Errorf("agent: upstream call failed: %w", err)
+		}
+
+		calls := functionCalls(resp)
+		if len(calls) == 0 {
+			return resp, nil
+		}
+
+		modelTurn := gjson.GetBytes(resp, "candidates.0.content")
+		current, err = appendContent(current, modelTurn.Raw)
+		if err != nil {
+			return nil, err
+		}
+
+		responses := dispatchCalls(ctx, calls, toolbox, opts.allowedSet(), opts)
+		current, err = appendContent(current, responseTurn(responses))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, ErrMaxStepsExceeded
+}
+
+type functionCall struct {
+	ID   string
+	Name string
+	Args []byte
+}
+
+func functionCalls(resp []byte) []functionCall {
+	var calls []functionCall
+	gjson.GetBytes(resp, "candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+		fc := part.Get("functionCall")
+		if !fc.Exists() {
+			return true
+		}
+		calls = append(calls, functionCall{
+			ID:   fc.Get("id").String(),
+			Name: fc.Get("name").String(),
+			Args: []byte(fc.Get("args").Raw),
+		})
+		return true
+	})
+	return calls
+}
+
+// dispatchCalls executes calls concurrently (one goroutine per call), but
+// funnels every opts.OnEvent invocation through a single mutex, emitting each
+// call's tool_use and tool_result together once that call has finished, so
+// integrators who write straight to an http.ResponseWriter from OnEvent never
+// see two calls overlap or interleave, even when the model requested several
+// tools in one turn.
+func dispatchCalls(ctx context.Context, calls []functionCall, toolbox *Toolbox, allowed map[string]bool, opts Options) []functionResult {
+	results := make([]functionResult, len(calls))
+
+	var wg sync.WaitGroup
+	var onEventMu sync.Mutex
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call functionCall) {
+			defer wg.Done()
+			results[i] = dispatchOne(ctx, call, toolbox, allowed, opts, &onEventMu)
+		}(i, call)
+	}
+	wg.Wait()
+
+	return results
+}
+
+type functionResult struct {
+	ID      string
+	Name    string
+	Content []byte
+}
+
+func dispatchOne(ctx context.Context, call functionCall, toolbox *Toolbox, allowed map[string]bool, opts Options, onEventMu *sync.Mutex) functionResult {
+	content := executeCall(ctx, call, toolbox, allowed, opts)
+
+	if opts.OnEvent != nil {
+		onEventMu.Lock()
+		opts.OnEvent(Event{Kind: EventToolUse, ID: call.ID, Name: call.Name, Args: call.Args})
+		opts.OnEvent(Event{Kind: EventToolResult, ID: call.ID, Name: call.Name, Args: content})
+		onEventMu.Unlock()
+	}
+
+	return functionResult{ID: call.ID, Name: call.Name, Content: content}
+}
+
+func executeCall(ctx context.Context, call functionCall, toolbox *Toolbox, allowed map[string]bool, opts Options) []byte {
+	tool, ok := toolbox.Lookup(call.Name)
+	if !ok || !allowed[call.Name] {
+		return errorContent(ErrToolNotAllowed)
+	}
+
+	if opts.Confirm != nil && !opts.Confirm(ctx, call.Name, call.Args) {
+		return errorContent(fmt.Errorf("agent: execution of %q was declined", call.Name))
+	}
+
+	result, err := tool.Execute(ctx, call.Args)
+	if err != nil {
+		return errorContent(err)
+	}
+	return result
+}
+
+func errorContent(err error) []byte {
+	out, _ := sjson.SetBytes([]byte(`{}`), "error", err.Error())
+	return out
+}
+
+// appendContent appends a raw Gemini `content` object (as produced by the
+// model, or synthesized for tool responses) to req's `request.contents`
+// array.
+func appendContent(req []byte, content string) ([]byte, error) {
+	if content == "" {
+		return req, nil
+	}
+	return sjson.SetRawBytes(req, "request.contents.-1", []byte(content))
+}
+
+// responseTurn packages dispatched tool results into a single `user`-role
+// content object carrying one functionResponse part per call, matching how
+// the translators fold tool_result blocks into the conversation.
+func responseTurn(results []functionResult) string {
+	turn := []byte(`{"role":"user","parts":[]}`)
+	for _, r := range results {
+		part := []byte(`{}`)
+		part, _ = sjson.SetBytes(part, "functionResponse.id", r.ID)
+		part, _ = sjson.SetBytes(part, "functionResponse.name", r.Name)
+		if len(r.Content) > 0 {
+			part, _ = sjson.SetRawBytes(part, "functionResponse.response", r.Content)
+		} else {
+			part, _ = sjson.SetRawBytes(part, "functionResponse.response", []byte(`{}`))
+		}
+		turn, _ = sjson.SetRawBytes(turn, "parts.-1", part)
+	}
+	return string(turn)
+}
@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// echoTool returns its "value" argument back as the result content, useful
+// for asserting the loop threads functionResponse content correctly.
+type echoTool struct{}
+
+func (echoTool) Name() string        { return "Echo" }
+func (echoTool) Description() string { return "Echoes its input back." }
+func (echoTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object"}`)
+}
+func (echoTool) Execute(_ context.Context, args json.RawMessage) (json.RawMessage, error) {
+	return args, nil
+}
+
+// scriptedUpstream returns one scripted response per call, in order.
+type scriptedUpstream struct {
+	responses [][]byte
+	calls     int
+}
+
+func (u *scriptedUpstream) Send(_ context.Context, _ []byte) ([]byte, error) {
+	resp := u.responses[u.calls]
+	u.calls++
+	return resp, nil
+}
+
+func TestRunToolLoop_TerminalTextTurnReturnsImmediately(t *testing.T) {
+	upstream := &scriptedUpstream{responses: [][]byte{
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"hi"}]}}]}`),
+	}}
+
+	resp, err := RunToolLoop(context.Background(), []byte(`{}`), NewToolbox(), upstream, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gjson.GetBytes(resp, "candidates.0.content.parts.0.text").String() != "hi" {
+		t.Errorf("expected terminal text turn to be returned unchanged")
+	}
+	if upstream.calls != 1 {
+		t.Errorf("expected exactly 1 upstream call, got %d", upstream.calls)
+	}
+}
+
+func TestRunToolLoop_DispatchesAllowedToolAndResumes(t *testing.T) {
+	toolbox := NewToolbox()
+	toolbox.Register(echoTool{})
+
+	upstream := &scriptedUpstream{responses: [][]byte{
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"call_1","name":"Echo","args":{"value":"hello"}}}]}}]}`),
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"done"}]}}]}`),
+	}}
+
+	resp, err := RunToolLoop(context.Background(), []byte(`{"request":{}}`), toolbox, upstream, Options{Allowed: []string{"Echo"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gjson.GetBytes(resp, "candidates.0.content.parts.0.text").String() != "done" {
+		t.Errorf("expected final terminal text turn 'done'")
+	}
+	if upstream.calls != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", upstream.calls)
+	}
+}
+
+func TestRunToolLoop_DisallowedToolReportsError(t *testing.T) {
+	toolbox := NewToolbox()
+	toolbox.Register(echoTool{}) // registered but not in Options.Allowed
+
+	upstream := &scriptedUpstream{responses: [][]byte{
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"call_1","name":"Echo","args":{}}}]}}]}`),
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"done"}]}}]}`),
+	}}
+
+	var events []Event
+	opts := Options{OnEvent: func(e Event) { events = append(events, e) }}
+
+	_, err := RunToolLoop(context.Background(), []byte(`{"request":{}}`), toolbox, upstream, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected tool_use and tool_result events, got %d", len(events))
+	}
+	if events[1].Kind != EventToolResult || gjson.GetBytes(events[1].Args, "error").String() == "" {
+		t.Errorf("expected tool_result event to carry an error for a disallowed tool")
+	}
+}
+
+func TestRunToolLoop_MaxStepsExceeded(t *testing.T) {
+	toolbox := NewToolbox()
+	toolbox.Register(echoTool{})
+
+	loopResponse := []byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"call_1","name":"Echo","args":{}}}]}}]}`)
+	upstream := &scriptedUpstream{responses: [][]byte{loopResponse, loopResponse, loopResponse}}
+
+	_, err := RunToolLoop(context.Background(), []byte(`{"request":{}}`), toolbox, upstream, Options{MaxSteps: 3, Allowed: []string{"Echo"}})
+	if err != ErrMaxStepsExceeded {
+		t.Fatalf("expected ErrMaxStepsExceeded, got %v", err)
+	}
+}
+
+// TestRunToolLoop_ConcurrentRequestsDifferentAllowlistsDontLeak drives two
+// "requests" against one shared Toolbox concurrently, each with a different
+// Options.Allowed, and asserts neither request's allowlist affects the
+// other's outcome — the property a mutable Toolbox.allowlist used to violate.
+func TestRunToolLoop_ConcurrentRequestsDifferentAllowlistsDontLeak(t *testing.T) {
+	toolbox := NewToolbox()
+	toolbox.Register(echoTool{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			allowed := i%2 == 0
+			var result Event
+			opts := Options{OnEvent: func(e Event) {
+				if e.Kind == EventToolResult {
+					result = e
+				}
+			}}
+			if allowed {
+				opts.Allowed = []string{"Echo"}
+			}
+
+			upstream := &scriptedUpstream{responses: [][]byte{
+				[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"call_1","name":"Echo","args":{}}}]}}]}`),
+				[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"done"}]}}]}`),
+			}}
+
+			resp, err := RunToolLoop(context.Background(), []byte(`{"request":{}}`), toolbox, upstream, opts)
+			if err != nil {
+				t.Errorf("request %d: unexpected error: %v", i, err)
+				return
+			}
+			if gjson.GetBytes(resp, "candidates.0.content.parts.0.text").String() != "done" {
+				t.Errorf("request %d: expected terminal text turn 'done'", i)
+			}
+			if rejected := gjson.GetBytes(result.Args, "error").Exists(); rejected == allowed {
+				t.Errorf("request %d: allowed=%v but tool_result error present=%v", i, allowed, rejected)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestRunToolLoop_ParallelToolCallsSerializeOnEvent drives a single model
+// turn containing multiple functionCall parts and asserts OnEvent still
+// observes one complete tool_use/tool_result pair at a time, never an
+// interleaved tool_use from one call followed by another call's tool_use
+// before the first call's tool_result.
+func TestRunToolLoop_ParallelToolCallsSerializeOnEvent(t *testing.T) {
+	toolbox := NewToolbox()
+	toolbox.Register(echoTool{})
+
+	const numCalls = 8
+	parts := ""
+	for i := 0; i < numCalls; i++ {
+		if i > 0 {
+			parts += ","
+		}
+		parts += fmt.Sprintf(`{"functionCall":{"id":"call_%d","name":"Echo","args":{"value":%d}}}`, i, i)
+	}
+	turn := []byte(fmt.Sprintf(`{"candidates":[{"content":{"role":"model","parts":[%s]}}]}`, parts))
+
+	upstream := &scriptedUpstream{responses: [][]byte{
+		turn,
+		[]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"done"}]}}]}`),
+	}}
+
+	var mu sync.Mutex
+	var events []Event
+	open := false
+	opts := Options{
+		Allowed: []string{"Echo"},
+		OnEvent: func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+			switch e.Kind {
+			case EventToolUse:
+				if open {
+					t.Errorf("tool_use for %s observed while another call was still open", e.ID)
+				}
+				open = true
+			case EventToolResult:
+				if !open {
+					t.Errorf("tool_result for %s observed without a matching open tool_use", e.ID)
+				}
+				open = false
+			}
+		},
+	}
+
+	if _, err := RunToolLoop(context.Background(), []byte(`{"request":{}}`), toolbox, upstream, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != numCalls*2 {
+		t.Fatalf("expected %d events, got %d", numCalls*2, len(events))
+	}
+}
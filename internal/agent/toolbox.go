@@ -0,0 +1,63 @@
+// Package agent implements a server-side agentic tool-call loop: when an
+// upstream Antigravity response comes back with one or more functionCall
+// parts, the loop dispatches them to a registered Toolbox and re-invokes the
+// upstream with the results until the model produces a terminal text turn.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Tool is a single server-executable function. InputSchema is expressed in
+// Claude's `input_schema` shape so it flows through the existing
+// Claude-format tool translation unchanged.
+type Tool interface {
+	Name() string
+	Description() string
+	InputSchema() json.RawMessage
+	Execute(ctx context.Context, args json.RawMessage) (json.RawMessage, error)
+}
+
+// Toolbox holds the set of tools a RunToolLoop invocation may call. It is
+// built once (typically at startup) and safe to share, read-only, across
+// concurrent requests; which of its tools a given request may actually
+// execute is a per-request decision, scoped via Options.Allowed rather than
+// mutated onto the Toolbox itself (see Options.Allowed).
+type Toolbox struct {
+	tools map[string]Tool
+}
+
+// NewToolbox creates an empty Toolbox. Tools must be registered with
+// Register before they can be dispatched.
+func NewToolbox() *Toolbox {
+	return &Toolbox{tools: make(map[string]Tool)}
+}
+
+// Register adds a tool to the box. Call this for every built-in/integrator
+// tool before the Toolbox is shared across requests; Register itself isn't
+// synchronized, so registering concurrently with a RunToolLoop call using
+// the same Toolbox is not safe.
+func (b *Toolbox) Register(tool Tool) {
+	b.tools[tool.Name()] = tool
+}
+
+// Lookup returns the registered tool with the given name, if any.
+func (b *Toolbox) Lookup(name string) (Tool, bool) {
+	tool, ok := b.tools[name]
+	return tool, ok
+}
+
+// Declarations returns every registered tool's schema in the Claude
+// `name`/`description`/`input_schema` shape, ready to be embedded in a
+// request's `tools` array so the translator picks them up unchanged.
+func (b *Toolbox) Declarations() []json.RawMessage {
+	decls := make([]json.RawMessage, 0, len(b.tools))
+	for _, tool := range b.tools {
+		decl := fmt.Sprintf(`{"name":%q,"description":%q,"input_schema":%s}`,
+			tool.Name(), tool.Description(), tool.InputSchema())
+		decls = append(decls, json.RawMessage(decl))
+	}
+	return decls
+}
@@ -0,0 +1,65 @@
+// Package anthropic registers the native Anthropic Messages API backend as a
+// providers.Provider. The client already speaks Anthropic's wire format (the
+// same shape `/v1/messages` accepts), so there is nothing to translate.
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/providers"
+)
+
+func init() {
+	providers.Register("anthropic", New)
+}
+
+// Config holds the Anthropic-specific settings a provider instance needs.
+type Config struct {
+	Endpoint string `json:"endpoint"`
+	APIKey   string `json:"api_key"`
+}
+
+// Provider passes a request straight through to the native Anthropic
+// Messages API; the client already speaks Anthropic's wire format, so there
+// is nothing to translate.
+type Provider struct {
+	config Config
+}
+
+// New builds a Provider from raw JSON config, defaulting Endpoint when unset.
+func New(rawConfig json.RawMessage) (providers.Provider, error) {
+	var cfg Config
+	if len(rawConfig) > 0 {
+		if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+			return nil, fmt.Errorf("anthropic: invalid config: %w", err)
+		}
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://api.anthropic.com/v1/messages"
+	}
+	return &Provider{config: cfg}, nil
+}
+
+func (p *Provider) Endpoint() string { return p.config.Endpoint }
+
+func (p *Provider) Auth(req *http.Request) error {
+	if p.config.APIKey != "" {
+		req.Header.Set("x-api-key", p.config.APIKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	}
+	return nil
+}
+
+func (p *Provider) TranslateRequest(req providers.NormalizedRequest) ([]byte, error) {
+	return req.MarshalClientJSON()
+}
+
+func (p *Provider) TranslateResponse(_ string, rawJSON []byte) ([]byte, error) {
+	return rawJSON, nil
+}
+
+func (p *Provider) TranslateStreamChunk(_ string, rawJSON []byte) ([]byte, error) {
+	return rawJSON, nil
+}
@@ -0,0 +1,70 @@
+// Package antigravity registers the Antigravity/Gemini CLI backend as a
+// providers.Provider, wrapping the existing OpenAI-compatible translator so
+// it can be selected through the same registry as every other backend.
+package antigravity
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/providers"
+	chat_completions "github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/openai/chat-completions"
+)
+
+func init() {
+	providers.Register("antigravity", New)
+}
+
+// Config holds the Antigravity-specific settings a provider instance needs.
+type Config struct {
+	Endpoint string `json:"endpoint"`
+	APIKey   string `json:"api_key"`
+}
+
+// Provider dispatches through the Antigravity/Gemini CLI backend.
+type Provider struct {
+	config Config
+}
+
+// New builds a Provider from raw JSON config, defaulting Endpoint when unset.
+func New(rawConfig json.RawMessage) (providers.Provider, error) {
+	var cfg Config
+	if len(rawConfig) > 0 {
+		if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+			return nil, fmt.Errorf("antigravity: invalid config: %w", err)
+		}
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://antigravity.googleapis.com/v1/generateContent"
+	}
+	return &Provider{config: cfg}, nil
+}
+
+func (p *Provider) Endpoint() string { return p.config.Endpoint }
+
+func (p *Provider) Auth(req *http.Request) error {
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+	return nil
+}
+
+func (p *Provider) TranslateRequest(req providers.NormalizedRequest) ([]byte, error) {
+	clientJSON, err := req.MarshalClientJSON()
+	if err != nil {
+		return nil, err
+	}
+	return chat_completions.ConvertOpenAIRequestToAntigravity(req.Model, clientJSON, req.Stream), nil
+}
+
+// TranslateResponse and TranslateStreamChunk are identity passthroughs: the
+// Antigravity backend's response shape is already what downstream handling
+// in this codebase expects.
+func (p *Provider) TranslateResponse(_ string, rawJSON []byte) ([]byte, error) {
+	return rawJSON, nil
+}
+
+func (p *Provider) TranslateStreamChunk(_ string, rawJSON []byte) ([]byte, error) {
+	return rawJSON, nil
+}
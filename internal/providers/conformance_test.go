@@ -0,0 +1,132 @@
+// This file ports the Claude/Cursor tool-definition compatibility coverage
+// from the chat-completions translator tests to run against every
+// registered backend Provider, so adding a new provider can't silently drop
+// Claude-format tool handling.
+package providers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/providers"
+	_ "github.com/safzanpirani/CLIProxyAPIPlus/internal/providers/anthropic"
+	_ "github.com/safzanpirani/CLIProxyAPIPlus/internal/providers/antigravity"
+	_ "github.com/safzanpirani/CLIProxyAPIPlus/internal/providers/gemini"
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/providers/openai"
+	_ "github.com/safzanpirani/CLIProxyAPIPlus/internal/providers/vertex"
+)
+
+func TestProviders_ClaudeToolDefinitionSurvivesTranslation(t *testing.T) {
+	req := providers.NormalizedRequest{
+		Model:    "gemini-2.5-pro",
+		Messages: json.RawMessage(`[{"role":"user","content":"Hello"}]`),
+		Tools: json.RawMessage(`[{
+			"name": "Shell",
+			"description": "Executes a shell command",
+			"input_schema": {
+				"type": "object",
+				"properties": {"command": {"type": "string"}},
+				"required": ["command"]
+			}
+		}]`),
+	}
+
+	for _, name := range []string{"antigravity", "gemini", "vertex"} {
+		t.Run(name, func(t *testing.T) {
+			provider, err := providers.New(name, nil)
+			if err != nil {
+				t.Fatalf("building provider %q failed: %v", name, err)
+			}
+
+			out, err := provider.TranslateRequest(req)
+			if err != nil {
+				t.Fatalf("TranslateRequest failed: %v", err)
+			}
+
+			funcDecl := gjson.GetBytes(out, "tools.0.functionDeclarations.0")
+			if !funcDecl.Exists() {
+				// antigravity wraps its output in a {model, request} envelope;
+				// gemini/vertex return the request body unwrapped.
+				funcDecl = gjson.GetBytes(out, "request.tools.0.functionDeclarations.0")
+			}
+
+			if !funcDecl.Exists() {
+				t.Fatalf("provider %q: functionDeclarations.0 should exist in %s", name, out)
+			}
+			if funcDecl.Get("name").String() != "Shell" {
+				t.Errorf("provider %q: expected tool name 'Shell', got %q", name, funcDecl.Get("name").String())
+			}
+			if !funcDecl.Get("parametersJsonSchema.properties.command").Exists() {
+				t.Errorf("provider %q: expected 'command' property in schema", name)
+			}
+		})
+	}
+}
+
+// TestProviders_AnthropicPassthroughDoesNotTranslate guards against the
+// "anthropic" provider routing through the Antigravity translator: the
+// native Anthropic Messages API already speaks this wire format, so
+// TranslateRequest must hand the client's tool definitions back unchanged
+// (`input_schema`), not Gemini's `functionDeclarations`/`parametersJsonSchema`.
+func TestProviders_AnthropicPassthroughDoesNotTranslate(t *testing.T) {
+	provider, err := providers.New("anthropic", nil)
+	if err != nil {
+		t.Fatalf("building provider failed: %v", err)
+	}
+
+	req := providers.NormalizedRequest{
+		Model:    "claude-opus-4",
+		Messages: json.RawMessage(`[{"role":"user","content":"Hello"}]`),
+		Tools: json.RawMessage(`[{
+			"name": "Shell",
+			"description": "Executes a shell command",
+			"input_schema": {
+				"type": "object",
+				"properties": {"command": {"type": "string"}},
+				"required": ["command"]
+			}
+		}]`),
+	}
+
+	out, err := provider.TranslateRequest(req)
+	if err != nil {
+		t.Fatalf("TranslateRequest failed: %v", err)
+	}
+
+	if gjson.GetBytes(out, "model").String() != "claude-opus-4" {
+		t.Errorf("expected model to pass through unchanged, got %q", gjson.GetBytes(out, "model").String())
+	}
+	tool := gjson.GetBytes(out, "tools.0")
+	if tool.Get("name").String() != "Shell" {
+		t.Errorf("expected tool name 'Shell' to pass through unchanged, got %q", tool.Get("name").String())
+	}
+	if !tool.Get("input_schema.properties.command").Exists() {
+		t.Error("expected input_schema to pass through unchanged, not be translated to parametersJsonSchema")
+	}
+	if gjson.GetBytes(out, "tools.0.functionDeclarations").Exists() {
+		t.Error("anthropic provider must not translate tools into Gemini's functionDeclarations shape")
+	}
+}
+
+// TestProviders_OpenAINotSelectableUntilTranslationExists guards against the
+// "openai" provider being reachable via the registry before it actually
+// implements Anthropic<->OpenAI Chat Completions translation: the proxy's
+// only inbound route is Anthropic-shaped, and this provider's
+// TranslateRequest/TranslateResponse/TranslateStreamChunk are still stubs, so
+// providers.New must fail rather than hand back something that would forward
+// a malformed body upstream.
+func TestProviders_OpenAINotSelectableUntilTranslationExists(t *testing.T) {
+	if _, err := providers.New("openai", nil); err == nil {
+		t.Fatal("expected providers.New(\"openai\", ...) to fail since openai is not registered")
+	}
+
+	provider, err := openai.New(nil)
+	if err != nil {
+		t.Fatalf("openai.New failed: %v", err)
+	}
+	if _, err := provider.TranslateRequest(providers.NormalizedRequest{Model: "gpt-4o"}); err == nil {
+		t.Error("expected TranslateRequest to report it is not implemented yet")
+	}
+}
@@ -0,0 +1,79 @@
+// Package gemini registers the native Gemini `generateContent` API as a
+// providers.Provider. It reuses the Anthropic translator's contents/tools
+// construction (the Gemini `request` envelope already matches
+// generateContent's body shape) rather than duplicating that logic.
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/providers"
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/anthropic/messages"
+)
+
+func init() {
+	providers.Register("gemini", New)
+}
+
+// Config holds the native Gemini API settings a provider instance needs.
+type Config struct {
+	Endpoint string `json:"endpoint"`
+	APIKey   string `json:"api_key"`
+}
+
+// Provider dispatches through the native Gemini `generateContent` API.
+type Provider struct {
+	config Config
+}
+
+// New builds a Provider from raw JSON config, defaulting Endpoint when unset.
+func New(rawConfig json.RawMessage) (providers.Provider, error) {
+	var cfg Config
+	if len(rawConfig) > 0 {
+		if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+			return nil, fmt.Errorf("gemini: invalid config: %w", err)
+		}
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://generativelanguage.googleapis.com/v1beta/models"
+	}
+	return &Provider{config: cfg}, nil
+}
+
+func (p *Provider) Endpoint() string { return p.config.Endpoint }
+
+func (p *Provider) Auth(req *http.Request) error {
+	if p.config.APIKey != "" {
+		q := req.URL.Query()
+		q.Set("key", p.config.APIKey)
+		req.URL.RawQuery = q.Encode()
+	}
+	return nil
+}
+
+// TranslateRequest builds a native generateContent body: `{contents, tools,
+// systemInstruction, generationConfig}`, with no enclosing `model`/`request`
+// wrapper (the model is part of the generateContent URL, not the body).
+func (p *Provider) TranslateRequest(req providers.NormalizedRequest) ([]byte, error) {
+	clientJSON, err := req.MarshalClientJSON()
+	if err != nil {
+		return nil, err
+	}
+	antigravityEnvelope := messages.ConvertAnthropicRequestToAntigravity(req.Model, clientJSON, req.Stream)
+	return []byte(gjson.GetBytes(antigravityEnvelope, "request").Raw), nil
+}
+
+// TranslateResponse and TranslateStreamChunk are identity passthroughs:
+// generateContent's response shape (`candidates[].content.parts`) is already
+// what this codebase's Antigravity response handling expects.
+func (p *Provider) TranslateResponse(_ string, rawJSON []byte) ([]byte, error) {
+	return rawJSON, nil
+}
+
+func (p *Provider) TranslateStreamChunk(_ string, rawJSON []byte) ([]byte, error) {
+	return rawJSON, nil
+}
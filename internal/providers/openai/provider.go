@@ -0,0 +1,69 @@
+// Package openai holds the config, endpoint and auth for an OpenAI Chat
+// Completions backend as a providers.Provider; it does not translate
+// requests or responses.
+//
+// Unlike the anthropic provider, this one is NOT a passthrough: the proxy's
+// only inbound route, `/v1/messages`, is Anthropic-shaped (top-level
+// `system`, Claude content blocks, `input_schema` tools), and none of that
+// is valid OpenAI Chat Completions wire format. TranslateRequest/
+// TranslateResponse/TranslateStreamChunk below are unimplemented stubs, and
+// New deliberately is not registered with providers.Register, so
+// `providers.ResolveModel` can't select "openai" until real bidirectional
+// translation exists here. Wiring it up early would let an `openai/<model>`
+// request silently send a malformed upstream body and return silently-wrong
+// output, rather than failing loudly.
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/providers"
+)
+
+// Config holds the OpenAI-specific settings a provider instance needs.
+type Config struct {
+	Endpoint string `json:"endpoint"`
+	APIKey   string `json:"api_key"`
+}
+
+// Provider is not yet wired up; see the package doc comment.
+type Provider struct {
+	config Config
+}
+
+// New builds a Provider from raw JSON config, defaulting Endpoint when unset.
+func New(rawConfig json.RawMessage) (providers.Provider, error) {
+	var cfg Config
+	if len(rawConfig) > 0 {
+		if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+			return nil, fmt.Errorf("openai: invalid config: %w", err)
+		}
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://api.openai.com/v1/chat/completions"
+	}
+	return &Provider{config: cfg}, nil
+}
+
+func (p *Provider) Endpoint() string { return p.config.Endpoint }
+
+func (p *Provider) Auth(req *http.Request) error {
+	if p.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+	return nil
+}
+
+func (p *Provider) TranslateRequest(req providers.NormalizedRequest) ([]byte, error) {
+	return nil, fmt.Errorf("openai: request translation is not implemented yet")
+}
+
+func (p *Provider) TranslateResponse(_ string, rawJSON []byte) ([]byte, error) {
+	return nil, fmt.Errorf("openai: response translation is not implemented yet")
+}
+
+func (p *Provider) TranslateStreamChunk(_ string, rawJSON []byte) ([]byte, error) {
+	return nil, fmt.Errorf("openai: stream chunk translation is not implemented yet")
+}
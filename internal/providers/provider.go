@@ -0,0 +1,129 @@
+// Package providers defines the pluggable backend interface translated
+// requests are dispatched through, and a name-keyed registry so additional
+// backends (native Gemini, Vertex AI, Anthropic, OpenAI, ...)
+// can be added without the call sites that pick a backend needing to change.
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/sjson"
+)
+
+// NormalizedRequest is the client-format-agnostic shape callers build once
+// and every Provider.TranslateRequest implementation can read from, so
+// routing and logging don't need to know which wire format the client used.
+type NormalizedRequest struct {
+	Model          string
+	System         string
+	Messages       json.RawMessage
+	Tools          json.RawMessage
+	ToolChoice     json.RawMessage
+	ResponseFormat json.RawMessage
+	Stream         bool
+	Temperature    *float64
+	MaxTokens      *int
+}
+
+// MarshalClientJSON renders req as a single JSON object carrying every field
+// under its OpenAI/Anthropic-compatible key (`messages`, `tools`,
+// `tool_choice`, `system`, `response_format`). Both translator families key
+// off whichever of those fields they understand, so this one superset body
+// can feed any of them without the caller needing per-provider branching.
+func (r NormalizedRequest) MarshalClientJSON() ([]byte, error) {
+	out := []byte(`{}`)
+	var err error
+	out, err = sjson.SetBytes(out, "model", r.Model)
+	if err != nil {
+		return nil, err
+	}
+	out, _ = sjson.SetBytes(out, "stream", r.Stream)
+	if r.System != "" {
+		out, _ = sjson.SetBytes(out, "system", r.System)
+	}
+	if len(r.Messages) > 0 {
+		out, _ = sjson.SetRawBytes(out, "messages", r.Messages)
+	}
+	if len(r.Tools) > 0 {
+		out, _ = sjson.SetRawBytes(out, "tools", r.Tools)
+	}
+	if len(r.ToolChoice) > 0 {
+		out, _ = sjson.SetRawBytes(out, "tool_choice", r.ToolChoice)
+	}
+	if len(r.ResponseFormat) > 0 {
+		out, _ = sjson.SetRawBytes(out, "response_format", r.ResponseFormat)
+	}
+	if r.Temperature != nil {
+		out, _ = sjson.SetBytes(out, "temperature", *r.Temperature)
+	}
+	if r.MaxTokens != nil {
+		out, _ = sjson.SetBytes(out, "max_tokens", *r.MaxTokens)
+	}
+	return out, nil
+}
+
+// Provider translates between a normalized request/response and whatever
+// wire format a specific backend (Antigravity, native Gemini, Vertex AI,
+// Anthropic, OpenAI, ...) speaks.
+type Provider interface {
+	// Endpoint returns the upstream URL this provider sends requests to.
+	Endpoint() string
+	// Auth attaches whatever credentials this provider needs to req.
+	Auth(req *http.Request) error
+	// TranslateRequest converts a NormalizedRequest into the backend's native
+	// request body.
+	TranslateRequest(req NormalizedRequest) ([]byte, error)
+	// TranslateResponse converts a non-streaming backend response body into
+	// the client-facing response shape this provider was selected to serve.
+	TranslateResponse(modelName string, rawJSON []byte) ([]byte, error)
+	// TranslateStreamChunk converts a single streamed backend chunk into the
+	// client-facing streaming frame(s), already formatted for the wire
+	// (e.g. `event: ...\ndata: ...\n\n` for SSE).
+	TranslateStreamChunk(modelName string, rawJSON []byte) ([]byte, error)
+}
+
+// Factory builds a Provider from backend-specific configuration (API keys,
+// project/location for Vertex, etc).
+type Factory func(config json.RawMessage) (Provider, error)
+
+var registry = map[string]Factory{}
+
+// Register adds a provider factory under name. Providers typically call this
+// from an init() function in their package.
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered under name, if any.
+func Lookup(name string) (Factory, bool) {
+	factory, ok := registry[name]
+	return factory, ok
+}
+
+// New builds a Provider by name, using the given backend-specific config.
+func New(name string, config json.RawMessage) (Provider, error) {
+	factory, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("providers: no provider registered under %q", name)
+	}
+	return factory(config)
+}
+
+// ResolveModel splits a model identifier carrying a provider prefix
+// (`gemini/gemini-2.5-pro`, `anthropic/claude-opus-4`, `vertex/...`) into the
+// provider name and the backend-specific model name. If model carries no
+// recognized prefix, ok is false and callers should fall back to a
+// configured default provider.
+func ResolveModel(model string) (providerName, backendModel string, ok bool) {
+	providerName, backendModel, found := strings.Cut(model, "/")
+	if !found || providerName == "" || backendModel == "" {
+		return "", "", false
+	}
+	if _, registered := registry[providerName]; !registered {
+		return "", "", false
+	}
+	return providerName, backendModel, true
+}
@@ -0,0 +1,89 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+type stubProvider struct{}
+
+func (stubProvider) Endpoint() string          { return "https://example.test" }
+func (stubProvider) Auth(_ *http.Request) error { return nil }
+func (stubProvider) TranslateRequest(_ NormalizedRequest) ([]byte, error) {
+	return []byte(`{}`), nil
+}
+func (stubProvider) TranslateResponse(_ string, raw []byte) ([]byte, error) {
+	return raw, nil
+}
+func (stubProvider) TranslateStreamChunk(_ string, raw []byte) ([]byte, error) {
+	return raw, nil
+}
+
+func TestRegisterAndLookup(t *testing.T) {
+	Register("stub", func(_ json.RawMessage) (Provider, error) { return stubProvider{}, nil })
+
+	factory, ok := Lookup("stub")
+	if !ok {
+		t.Fatal("expected stub provider to be registered")
+	}
+	provider, err := factory(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if provider.Endpoint() != "https://example.test" {
+		t.Errorf("expected stub endpoint, got %q", provider.Endpoint())
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	Register("stub", func(_ json.RawMessage) (Provider, error) { return stubProvider{}, nil })
+
+	tests := []struct {
+		model        string
+		wantProvider string
+		wantModel    string
+		wantOK       bool
+	}{
+		{"stub/gemini-2.5-pro", "stub", "gemini-2.5-pro", true},
+		{"gemini-2.5-pro", "", "", false},
+		{"unregistered/some-model", "", "", false},
+	}
+
+	for _, tc := range tests {
+		providerName, backendModel, ok := ResolveModel(tc.model)
+		if ok != tc.wantOK || providerName != tc.wantProvider || backendModel != tc.wantModel {
+			t.Errorf("ResolveModel(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tc.model, providerName, backendModel, ok, tc.wantProvider, tc.wantModel, tc.wantOK)
+		}
+	}
+}
+
+func TestNormalizedRequest_MarshalClientJSON(t *testing.T) {
+	req := NormalizedRequest{
+		Model:    "gemini-2.5-pro",
+		System:   "You are helpful.",
+		Messages: json.RawMessage(`[{"role":"user","content":"hi"}]`),
+		Tools:    json.RawMessage(`[{"name":"Shell","input_schema":{"type":"object"}}]`),
+		Stream:   true,
+	}
+
+	out, err := req.MarshalClientJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if _, ok := decoded["messages"]; !ok {
+		t.Error("expected messages field to be present")
+	}
+	if _, ok := decoded["tools"]; !ok {
+		t.Error("expected tools field to be present")
+	}
+	if _, ok := decoded["system"]; !ok {
+		t.Error("expected system field to be present")
+	}
+}
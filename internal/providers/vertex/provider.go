@@ -0,0 +1,80 @@
+// Package vertex registers the Vertex AI Gemini backend as a
+// providers.Provider. The request/response shapes are identical to native
+// Gemini `generateContent`; only the endpoint URL (project/location scoped)
+// and auth header differ.
+package vertex
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/providers"
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/anthropic/messages"
+)
+
+func init() {
+	providers.Register("vertex", New)
+}
+
+// Config holds the Vertex AI settings a provider instance needs: the GCP
+// project and region Vertex requests are scoped to, plus a bearer access
+// token (Vertex uses short-lived OAuth tokens, not a static API key).
+type Config struct {
+	Project     string `json:"project"`
+	Location    string `json:"location"`
+	AccessToken string `json:"access_token"`
+}
+
+// Provider dispatches through Vertex AI's Gemini endpoint.
+type Provider struct {
+	config Config
+}
+
+// New builds a Provider from raw JSON config.
+func New(rawConfig json.RawMessage) (providers.Provider, error) {
+	var cfg Config
+	if len(rawConfig) > 0 {
+		if err := json.Unmarshal(rawConfig, &cfg); err != nil {
+			return nil, fmt.Errorf("vertex: invalid config: %w", err)
+		}
+	}
+	if cfg.Location == "" {
+		cfg.Location = "us-central1"
+	}
+	return &Provider{config: cfg}, nil
+}
+
+func (p *Provider) Endpoint() string {
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1/projects/%s/locations/%s/publishers/google/models",
+		p.config.Location, p.config.Project, p.config.Location)
+}
+
+func (p *Provider) Auth(req *http.Request) error {
+	if p.config.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.AccessToken)
+	}
+	return nil
+}
+
+// TranslateRequest builds the same `{contents, tools, systemInstruction,
+// generationConfig}` body the native Gemini provider does; Vertex's
+// generateContent shape matches it exactly.
+func (p *Provider) TranslateRequest(req providers.NormalizedRequest) ([]byte, error) {
+	clientJSON, err := req.MarshalClientJSON()
+	if err != nil {
+		return nil, err
+	}
+	antigravityEnvelope := messages.ConvertAnthropicRequestToAntigravity(req.Model, clientJSON, req.Stream)
+	return []byte(gjson.GetBytes(antigravityEnvelope, "request").Raw), nil
+}
+
+func (p *Provider) TranslateResponse(_ string, rawJSON []byte) ([]byte, error) {
+	return rawJSON, nil
+}
+
+func (p *Provider) TranslateStreamChunk(_ string, rawJSON []byte) ([]byte, error) {
+	return rawJSON, nil
+}
@@ -0,0 +1,187 @@
+// Package server exposes the proxy's HTTP surface.
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/providers"
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/anthropic/messages"
+)
+
+// Upstream dispatches a translated Antigravity request and returns the raw
+// upstream response body. Streaming callers receive one chunk per call to
+// onChunk; non-streaming callers get a single chunk followed by a nil error.
+//
+// It serves every backend Provider the handler can select: each Provider's
+// TranslateRequest produces a body this same Upstream knows how to send, and
+// Gemini-shaped responses (from antigravity/gemini/vertex) all share the
+// `candidates[].content.parts` shape messages.ConvertAntigravityResponseToAnthropic
+// expects. Dispatching each provider to its own Endpoint()/Auth() over a real
+// HTTP client instead is future work — this handler only wires backend
+// *selection*, not a parallel network layer, since Upstream's concrete
+// implementation (outside this package) already owns the real dispatch.
+type Upstream interface {
+	Send(antigravityRequest []byte, stream bool, onChunk func([]byte) error) error
+}
+
+// defaultProviderName is the backend selected when a request's model carries
+// no "<provider>/" prefix, preserving the handler's original Antigravity-only
+// behavior.
+const defaultProviderName = "antigravity"
+
+// nativeProviderName is the one registered Provider whose response is
+// already Anthropic-shaped; every other provider's response is Gemini-shaped
+// and still needs messages.ConvertAntigravityResponseToAnthropic.
+const nativeProviderName = "anthropic"
+
+// MessagesHandler serves the native Anthropic Messages API (`/v1/messages`)
+// so Claude Code and Cursor can talk to the proxy without pretending to be
+// OpenAI clients. The backend it translates to is selected per request via
+// providers.ResolveModel, so a `gemini/gemini-2.5-pro`-style model routes
+// through the native Gemini translator while a bare model name keeps using
+// the default Antigravity backend.
+type MessagesHandler struct {
+	upstream Upstream
+}
+
+// NewMessagesHandler builds a MessagesHandler backed by the given upstream
+// dispatcher.
+func NewMessagesHandler(upstream Upstream) *MessagesHandler {
+	return &MessagesHandler{upstream: upstream}
+}
+
+// RegisterRoutes wires the handler onto the `/v1/messages` path.
+func (h *MessagesHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/messages", h.handle)
+}
+
+func (h *MessagesHandler) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	providerName, backendModel, ok := providers.ResolveModel(req.Model)
+	if !ok {
+		providerName, backendModel = defaultProviderName, req.Model
+	}
+	provider, err := providers.New(providerName, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	backendRequest, err := provider.TranslateRequest(normalizedAnthropicRequest(backendModel, req.Stream, body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !req.Stream {
+		var responseBody []byte
+		err := h.upstream.Send(backendRequest, false, func(chunk []byte) error {
+			responseBody = chunk
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(h.clientResponse(providerName, backendModel, responseBody))
+		return
+	}
+
+	h.handleStream(w, providerName, backendModel, backendRequest)
+}
+
+// normalizedAnthropicRequest lifts the fields a Provider.TranslateRequest
+// needs out of a native `/v1/messages` body.
+func normalizedAnthropicRequest(model string, stream bool, body []byte) providers.NormalizedRequest {
+	req := providers.NormalizedRequest{Model: model, Stream: stream}
+	if system := gjson.GetBytes(body, "system"); system.Exists() {
+		req.System = system.String()
+	}
+	if msgs := gjson.GetBytes(body, "messages"); msgs.Exists() {
+		req.Messages = []byte(msgs.Raw)
+	}
+	if tools := gjson.GetBytes(body, "tools"); tools.Exists() {
+		req.Tools = []byte(tools.Raw)
+	}
+	if toolChoice := gjson.GetBytes(body, "tool_choice"); toolChoice.Exists() {
+		req.ToolChoice = []byte(toolChoice.Raw)
+	}
+	if maxTokens := gjson.GetBytes(body, "max_tokens"); maxTokens.Exists() {
+		v := int(maxTokens.Int())
+		req.MaxTokens = &v
+	}
+	if temperature := gjson.GetBytes(body, "temperature"); temperature.Exists() {
+		v := temperature.Float()
+		req.Temperature = &v
+	}
+	return req
+}
+
+// clientResponse converts a non-streaming backend response into the
+// Anthropic Messages shape this handler always serves, regardless of which
+// backend produced it.
+func (h *MessagesHandler) clientResponse(providerName, model string, responseBody []byte) []byte {
+	if providerName == nativeProviderName {
+		return responseBody
+	}
+	return messages.ConvertAntigravityResponseToAnthropic(model, responseBody)
+}
+
+func (h *MessagesHandler) handleStream(w http.ResponseWriter, providerName, model string, backendRequest []byte) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	if providerName == nativeProviderName {
+		// The native Anthropic backend already streams Anthropic SSE frames;
+		// relay them unchanged.
+		_ = h.upstream.Send(backendRequest, true, func(chunk []byte) error {
+			_, err := w.Write(chunk)
+			flusher.Flush()
+			return err
+		})
+		return
+	}
+
+	translator := messages.NewStreamTranslator(model)
+	err := h.upstream.Send(backendRequest, true, func(chunk []byte) error {
+		for _, frame := range translator.Feed(chunk) {
+			if _, err := io.WriteString(w, frame); err != nil {
+				return err
+			}
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	_, _ = io.WriteString(w, translator.Close())
+	flusher.Flush()
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+
+	_ "github.com/safzanpirani/CLIProxyAPIPlus/internal/providers/anthropic"
+	_ "github.com/safzanpirani/CLIProxyAPIPlus/internal/providers/antigravity"
+)
+
+// fakeUpstream records the request it was asked to send and returns a
+// canned response, so tests can assert on what each Provider produced
+// without a real network dependency.
+type fakeUpstream struct {
+	gotRequest []byte
+	response   []byte
+}
+
+func (f *fakeUpstream) Send(antigravityRequest []byte, _ bool, onChunk func([]byte) error) error {
+	f.gotRequest = antigravityRequest
+	return onChunk(f.response)
+}
+
+func postMessages(t *testing.T, h *MessagesHandler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMessagesHandler_DefaultModelRoutesThroughAntigravity(t *testing.T) {
+	upstream := &fakeUpstream{
+		response: []byte(`{"candidates":[{"content":{"parts":[{"text":"hi"}]}}]}`),
+	}
+	h := NewMessagesHandler(upstream)
+
+	rec := postMessages(t, h, `{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"hello"}]}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if gjson.GetBytes(upstream.gotRequest, "request.contents.0.parts.0.text").String() != "hello" {
+		t.Errorf("expected the antigravity provider to translate the request, got %s", upstream.gotRequest)
+	}
+	if text := gjson.GetBytes(rec.Body.Bytes(), "content.0.text").String(); text != "hi" {
+		t.Errorf("expected the Gemini-shaped response to be converted to Anthropic shape, got %s", rec.Body.String())
+	}
+}
+
+func TestMessagesHandler_AnthropicPrefixedModelPassesThrough(t *testing.T) {
+	nativeResponse := `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"hi"}]}`
+	upstream := &fakeUpstream{response: []byte(nativeResponse)}
+	h := NewMessagesHandler(upstream)
+
+	rec := postMessages(t, h, `{"model":"anthropic/claude-3-opus","messages":[{"role":"user","content":"hello"}]}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Body.String() != nativeResponse {
+		t.Errorf("expected the native anthropic provider's response to pass through unchanged, got %s", rec.Body.String())
+	}
+}
+
+func TestMessagesHandler_UnrecognizedPrefixFallsBackToDefault(t *testing.T) {
+	// providers.ResolveModel treats an unregistered "<name>/" prefix the same
+	// as no prefix at all (ok=false), so the full model string is kept and
+	// routed to the default provider rather than rejected outright.
+	upstream := &fakeUpstream{
+		response: []byte(`{"candidates":[{"content":{"parts":[{"text":"hi"}]}}]}`),
+	}
+	h := NewMessagesHandler(upstream)
+
+	rec := postMessages(t, h, `{"model":"unknownvendor/some-model","messages":[{"role":"user","content":"hello"}]}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if gjson.GetBytes(upstream.gotRequest, "request.contents.0.parts.0.text").String() != "hello" {
+		t.Errorf("expected fallback to the default antigravity provider, got %s", upstream.gotRequest)
+	}
+}
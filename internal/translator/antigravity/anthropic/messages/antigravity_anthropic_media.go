@@ -0,0 +1,32 @@
+package messages
+
+import (
+	"github.com/tidwall/gjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/geminiparts"
+)
+
+// wantMedia registers a `source` block's remote URL (if any) with fetcher, so
+// it can be fetched concurrently with the rest of the request's media blocks
+// instead of serially inside mediaPart.
+func wantMedia(source gjson.Result, fetcher *geminiparts.RemoteMediaFetcher) {
+	if source.Get("type").String() == "url" {
+		fetcher.Want(source.Get("url").String())
+	}
+}
+
+// mediaPart converts a Claude `source` block (used by both `image` and
+// `document` content blocks) into a Gemini inlineData part. The `base64`
+// variant is inlined as-is; the `url` variant was looked up in fetched (the
+// result of a RemoteMediaFetcher.Fetch call made once for the whole request)
+// rather than being fetched here.
+func mediaPart(source gjson.Result, fetched map[string][]byte) []byte {
+	switch source.Get("type").String() {
+	case "base64":
+		return geminiparts.InlineData(source.Get("media_type").String(), source.Get("data").String())
+	case "url":
+		return fetched[source.Get("url").String()]
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,185 @@
+// Package messages provides request translation functionality for native
+// Anthropic Messages API (`/v1/messages`) to Gemini CLI API compatibility.
+package messages
+
+import (
+	"context"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/geminiparts"
+)
+
+// geminiCLIFunctionThoughtSignature is the sentinel geminiparts.FunctionCallPart
+// stamps onto synthesized functionCall parts, aliased here so existing tests
+// can keep asserting on it without reaching into another package.
+const geminiCLIFunctionThoughtSignature = geminiparts.ThoughtSignature
+
+// ConvertAnthropicRequestToAntigravity translates a native Anthropic Messages
+// API request (top-level `system`/`messages`/`tools`/`tool_choice`) into the
+// Antigravity/Gemini CLI request envelope. It reuses the same contents/tools
+// shape as the OpenAI translator so downstream handling (upstream dispatch,
+// response translation) doesn't need to know which client format was used.
+func ConvertAnthropicRequestToAntigravity(modelName string, rawJSON []byte, stream bool) []byte {
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	out, _ = sjson.SetBytes(out, "stream", stream)
+
+	msgs := gjson.GetBytes(rawJSON, "messages")
+	contents := buildContents(msgs, prefetchMedia(msgs))
+	out, _ = sjson.SetRawBytes(out, "request.contents", contents)
+
+	if tools := gjson.GetBytes(rawJSON, "tools"); tools.IsArray() {
+		if toolsOut := buildToolsPayload(tools); toolsOut != nil {
+			out, _ = sjson.SetRawBytes(out, "request.tools", toolsOut)
+		}
+	}
+
+	if toolConfig := buildToolConfig(gjson.GetBytes(rawJSON, "tool_choice")); toolConfig != nil {
+		out, _ = sjson.SetRawBytes(out, "request.toolConfig", toolConfig)
+	}
+
+	// Anthropic carries system prompt as a top-level field, either a plain
+	// string or a list of text blocks, rather than a "system" message.
+	if system := gjson.GetBytes(rawJSON, "system"); system.Exists() {
+		out, _ = sjson.SetBytes(out, "request.systemInstruction.parts.0.text", systemText(system))
+	}
+
+	if maxTokens := gjson.GetBytes(rawJSON, "max_tokens"); maxTokens.Exists() {
+		out, _ = sjson.SetBytes(out, "request.generationConfig.maxOutputTokens", maxTokens.Int())
+	}
+	if temperature := gjson.GetBytes(rawJSON, "temperature"); temperature.Exists() {
+		out, _ = sjson.SetBytes(out, "request.generationConfig.temperature", temperature.Float())
+	}
+
+	return out
+}
+
+func systemText(system gjson.Result) string {
+	if system.Type == gjson.String {
+		return system.String()
+	}
+	var text string
+	system.ForEach(func(_, block gjson.Result) bool {
+		if block.Get("type").String() == "text" {
+			text += block.Get("text").String()
+		}
+		return true
+	})
+	return text
+}
+
+// prefetchMedia walks every message's content blocks once up front and
+// fetches all the remote media URLs they reference concurrently (capped at
+// geminiparts.MaxRemoteMediaFetchesPerRequest), so a message carrying several
+// remote image/document blocks costs roughly one fetch's latency rather than
+// one mediafetch timeout per block.
+func prefetchMedia(msgs gjson.Result) map[string][]byte {
+	fetcher := geminiparts.NewRemoteMediaFetcher()
+	msgs.ForEach(func(_, msg gjson.Result) bool {
+		if content := msg.Get("content"); content.IsArray() {
+			content.ForEach(func(_, block gjson.Result) bool {
+				if t := block.Get("type").String(); t == "image" || t == "document" {
+					wantMedia(block.Get("source"), fetcher)
+				}
+				return true
+			})
+		}
+		return true
+	})
+	return fetcher.Fetch(context.Background())
+}
+
+// buildContents walks the Anthropic message list, translating each
+// `content` block (text, image, tool_use, tool_result) into Gemini `parts`.
+// fetched is the result of a prior prefetchMedia call, consulted for any
+// remote media block instead of fetching it inline.
+func buildContents(msgs gjson.Result, fetched map[string][]byte) []byte {
+	contents := []byte(`[]`)
+	toolCallNames := map[string]string{}
+
+	msgs.ForEach(func(_, msg gjson.Result) bool {
+		role := msg.Get("role").String()
+		geminiRole := "user"
+		if role == "assistant" {
+			geminiRole = "model"
+		}
+
+		parts := buildParts(msg.Get("content"), geminiRole, toolCallNames, fetched)
+		if len(parts) > 0 {
+			contents = geminiparts.AppendContent(contents, geminiRole, parts)
+		}
+		return true
+	})
+
+	return contents
+}
+
+func buildParts(content gjson.Result, role string, toolCallNames map[string]string, fetched map[string][]byte) [][]byte {
+	if content.Type == gjson.String {
+		if content.String() == "" {
+			return nil
+		}
+		return [][]byte{geminiparts.TextPart(content.String())}
+	}
+
+	if !content.IsArray() {
+		return nil
+	}
+
+	var parts [][]byte
+	content.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			if text := block.Get("text").String(); text != "" {
+				parts = append(parts, geminiparts.TextPart(text))
+			}
+		case "tool_use":
+			id := block.Get("id").String()
+			name := block.Get("name").String()
+			toolCallNames[id] = name
+			parts = append(parts, geminiparts.FunctionCallPart(id, name, block.Get("input")))
+		case "tool_result":
+			id := block.Get("tool_use_id").String()
+			parts = append(parts, geminiparts.FunctionResponsePart(id, toolCallNames[id], block.Get("content")))
+		case "image", "document":
+			if part := mediaPart(block.Get("source"), fetched); part != nil {
+				parts = append(parts, part)
+			}
+		}
+		return true
+	})
+	return parts
+}
+
+// buildToolsPayload converts Anthropic tool definitions
+// (`name`/`description`/`input_schema`) into a Gemini functionDeclarations
+// tool entry.
+func buildToolsPayload(tools gjson.Result) []byte {
+	return geminiparts.BuildToolsPayload(tools)
+}
+
+// buildToolConfig maps Anthropic's `tool_choice` onto Gemini's
+// `toolConfig.functionCallingConfig`.
+func buildToolConfig(toolChoice gjson.Result) []byte {
+	if !toolChoice.Exists() {
+		return nil
+	}
+
+	cfg := []byte(`{}`)
+	switch toolChoice.Get("type").String() {
+	case "auto":
+		cfg, _ = sjson.SetBytes(cfg, "functionCallingConfig.mode", "AUTO")
+	case "any":
+		cfg, _ = sjson.SetBytes(cfg, "functionCallingConfig.mode", "ANY")
+	case "tool":
+		cfg, _ = sjson.SetBytes(cfg, "functionCallingConfig.mode", "ANY")
+		cfg, _ = sjson.SetBytes(cfg, "functionCallingConfig.allowedFunctionNames.0", toolChoice.Get("name").String())
+	case "none":
+		cfg, _ = sjson.SetBytes(cfg, "functionCallingConfig.mode", "NONE")
+	default:
+		return nil
+	}
+	return cfg
+}
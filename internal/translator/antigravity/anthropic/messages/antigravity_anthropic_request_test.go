@@ -0,0 +1,152 @@
+// Package messages provides request translation functionality for native
+// Anthropic Messages API (`/v1/messages`) to Gemini CLI API compatibility.
+// Tests mirror the Claude/Cursor compatibility coverage in the OpenAI
+// chat-completions translator.
+package messages
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertAnthropicRequestToAntigravity_SystemAndText(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"system": "You are a helpful assistant.",
+		"messages": [{"role": "user", "content": "Hello"}]
+	}`)
+
+	output := ConvertAnthropicRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	if gjson.Get(outputStr, "request.systemInstruction.parts.0.text").String() != "You are a helpful assistant." {
+		t.Errorf("expected systemInstruction text to be set")
+	}
+	if gjson.Get(outputStr, "request.contents.0.parts.0.text").String() != "Hello" {
+		t.Errorf("expected first content part text 'Hello'")
+	}
+}
+
+func TestConvertAnthropicRequestToAntigravity_ToolDefinition(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [{"role": "user", "content": "Hello"}],
+		"tools": [
+			{
+				"name": "Shell",
+				"description": "Executes a shell command",
+				"input_schema": {
+					"type": "object",
+					"properties": {"command": {"type": "string"}},
+					"required": ["command"]
+				}
+			}
+		]
+	}`)
+
+	output := ConvertAnthropicRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	funcDecl := gjson.Get(outputStr, "request.tools.0.functionDeclarations.0")
+	if !funcDecl.Exists() {
+		t.Fatal("functionDeclarations.0 should exist")
+	}
+	if funcDecl.Get("name").String() != "Shell" {
+		t.Errorf("expected tool name 'Shell', got '%s'", funcDecl.Get("name").String())
+	}
+	if !funcDecl.Get("parametersJsonSchema.properties.command").Exists() {
+		t.Error("schema should have 'command' property")
+	}
+}
+
+func TestConvertAnthropicRequestToAntigravity_ToolUseAndResult(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{"role": "user", "content": "List files"},
+			{
+				"role": "assistant",
+				"content": [
+					{"type": "tool_use", "id": "call_abc123", "name": "Shell", "input": {"command": "ls -la"}}
+				]
+			},
+			{
+				"role": "user",
+				"content": [
+					{"type": "tool_result", "tool_use_id": "call_abc123", "content": [{"type": "text", "text": "file1.txt"}]}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertAnthropicRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	modelContent := gjson.Get(outputStr, "request.contents.1")
+	if modelContent.Get("role").String() != "model" {
+		t.Errorf("expected role 'model', got '%s'", modelContent.Get("role").String())
+	}
+	funcCall := modelContent.Get("parts.0.functionCall")
+	if funcCall.Get("name").String() != "Shell" {
+		t.Errorf("expected functionCall name 'Shell', got '%s'", funcCall.Get("name").String())
+	}
+	if modelContent.Get("parts.0.thoughtSignature").String() != geminiCLIFunctionThoughtSignature {
+		t.Errorf("expected thoughtSignature to be set")
+	}
+
+	funcResp := gjson.Get(outputStr, "request.contents.2.parts.0.functionResponse")
+	if funcResp.Get("id").String() != "call_abc123" {
+		t.Errorf("expected function response id 'call_abc123', got '%s'", funcResp.Get("id").String())
+	}
+	if funcResp.Get("name").String() != "Shell" {
+		t.Errorf("expected function response name 'Shell', got '%s'", funcResp.Get("name").String())
+	}
+}
+
+func TestConvertAnthropicRequestToAntigravity_ImageBlock(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "text", "text": "What is this?"},
+					{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "abc123"}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertAnthropicRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	parts := gjson.Get(outputStr, "request.contents.0.parts")
+	if !parts.IsArray() || len(parts.Array()) != 2 {
+		t.Fatalf("expected 2 parts (text + image), got %d", len(parts.Array()))
+	}
+	if parts.Array()[1].Get("inlineData.mimeType").String() != "image/png" {
+		t.Errorf("expected inlineData.mimeType 'image/png'")
+	}
+	if parts.Array()[1].Get("inlineData.data").String() != "abc123" {
+		t.Errorf("expected inlineData.data 'abc123'")
+	}
+}
+
+func TestConvertAnthropicRequestToAntigravity_ToolChoiceForcesFunction(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [{"role": "user", "content": "Hello"}],
+		"tool_choice": {"type": "tool", "name": "Shell"}
+	}`)
+
+	output := ConvertAnthropicRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	if gjson.Get(outputStr, "request.toolConfig.functionCallingConfig.mode").String() != "ANY" {
+		t.Errorf("expected functionCallingConfig.mode 'ANY'")
+	}
+	if gjson.Get(outputStr, "request.toolConfig.functionCallingConfig.allowedFunctionNames.0").String() != "Shell" {
+		t.Errorf("expected allowedFunctionNames.0 'Shell'")
+	}
+}
@@ -0,0 +1,211 @@
+package messages
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertAntigravityResponseToAnthropic translates a non-streaming
+// Antigravity/Gemini CLI response back into the shape an Anthropic Messages
+// API client expects: top-level `id`/`content` blocks plus
+// `stop_reason`/`usage`.
+func ConvertAntigravityResponseToAnthropic(modelName string, rawJSON []byte) []byte {
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "type", "message")
+	out, _ = sjson.SetBytes(out, "id", generateMessageID())
+	out, _ = sjson.SetBytes(out, "role", "assistant")
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	out, _ = sjson.SetRawBytes(out, "content", []byte(`[]`))
+
+	candidate := gjson.GetBytes(rawJSON, "candidates.0")
+	hasToolUse := false
+	candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+		if part.Get("functionCall").Exists() {
+			hasToolUse = true
+		}
+		if block := anthropicBlockFromPart(part); block != nil {
+			out, _ = sjson.SetRawBytes(out, "content.-1", block)
+		}
+		return true
+	})
+
+	out, _ = sjson.SetBytes(out, "stop_reason", anthropicStopReason(candidate.Get("finishReason").String(), hasToolUse))
+
+	if usage := gjson.GetBytes(rawJSON, "usageMetadata"); usage.Exists() {
+		out, _ = sjson.SetBytes(out, "usage.input_tokens", usage.Get("promptTokenCount").Int())
+		out, _ = sjson.SetBytes(out, "usage.output_tokens", usage.Get("candidatesTokenCount").Int())
+	}
+
+	return out
+}
+
+// generateMessageID produces an Anthropic-style `msg_...` identifier. Every
+// real Messages API response carries a top-level id; strict-validating
+// clients/SDKs reject a response that omits one.
+func generateMessageID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return "msg_" + hex.EncodeToString(buf)
+}
+
+func anthropicBlockFromPart(part gjson.Result) []byte {
+	switch {
+	case part.Get("text").Exists():
+		block, _ := sjson.SetBytes([]byte(`{}`), "type", "text")
+		block, _ = sjson.SetBytes(block, "text", part.Get("text").String())
+		return block
+	case part.Get("functionCall").Exists():
+		fc := part.Get("functionCall")
+		block, _ := sjson.SetBytes([]byte(`{}`), "type", "tool_use")
+		block, _ = sjson.SetBytes(block, "id", fc.Get("id").String())
+		block, _ = sjson.SetBytes(block, "name", fc.Get("name").String())
+		if args := fc.Get("args"); args.Exists() {
+			block, _ = sjson.SetRawBytes(block, "input", []byte(args.Raw))
+		} else {
+			block, _ = sjson.SetRawBytes(block, "input", []byte(`{}`))
+		}
+		return block
+	default:
+		return nil
+	}
+}
+
+// anthropicStopReason maps a Gemini finishReason onto an Anthropic
+// stop_reason. A turn that produced a tool call reports "tool_use"
+// regardless of finishReason (Gemini reports "STOP" for those turns too),
+// since that's what tells an Anthropic client to execute the tool and
+// continue the conversation rather than treat the turn as final.
+func anthropicStopReason(geminiFinishReason string, hasToolUse bool) string {
+	if hasToolUse {
+		return "tool_use"
+	}
+	switch geminiFinishReason {
+	case "MAX_TOKENS":
+		return "max_tokens"
+	default:
+		return "end_turn"
+	}
+}
+
+// StreamTranslator turns a sequence of streamed Antigravity/Gemini CLI
+// response chunks into the Anthropic Messages SSE event framing
+// (message_start, content_block_start/delta/stop, message_delta,
+// message_stop) that Claude Code and Cursor expect on `/v1/messages`.
+//
+// Callers feed it one upstream chunk at a time via Feed and drain whatever
+// SSE frames that chunk produced; Close emits the trailing message_stop.
+type StreamTranslator struct {
+	modelName   string
+	messageID   string
+	started     bool
+	blockOpen   bool
+	blockIndex  int
+	blockIsText bool
+	hasToolUse  bool
+}
+
+// NewStreamTranslator creates a translator for a single `/v1/messages`
+// streaming response.
+func NewStreamTranslator(modelName string) *StreamTranslator {
+	return &StreamTranslator{modelName: modelName, messageID: generateMessageID(), blockIndex: -1}
+}
+
+// Feed consumes one upstream JSON chunk (a single `candidates[0]` response as
+// emitted by the Gemini CLI streaming API) and returns the Anthropic SSE
+// event frames it produces, already formatted as `event: ...\ndata: ...\n\n`.
+func (t *StreamTranslator) Feed(rawJSON []byte) []string {
+	var frames []string
+
+	if !t.started {
+		t.started = true
+		frames = append(frames, t.sseFrame("message_start", t.messageStartPayload()))
+	}
+
+	candidate := gjson.GetBytes(rawJSON, "candidates.0")
+	candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+		frames = append(frames, t.framesForPart(part)...)
+		return true
+	})
+
+	if finish := candidate.Get("finishReason").String(); finish != "" {
+		if t.blockOpen {
+			frames = append(frames, t.sseFrame("content_block_stop", fmt.Sprintf(`{"type":"content_block_stop","index":%d}`, t.blockIndex)))
+			t.blockOpen = false
+		}
+		payload := fmt.Sprintf(`{"type":"message_delta","delta":{"stop_reason":"%s"}}`, anthropicStopReason(finish, t.hasToolUse))
+		frames = append(frames, t.sseFrame("message_delta", payload))
+	}
+
+	return frames
+}
+
+// Close emits the final `message_stop` frame; callers must call it exactly
+// once after the last Feed.
+func (t *StreamTranslator) Close() string {
+	return t.sseFrame("message_stop", `{"type":"message_stop"}`)
+}
+
+func (t *StreamTranslator) framesForPart(part gjson.Result) []string {
+	var frames []string
+
+	switch {
+	case part.Get("text").Exists():
+		if !t.blockOpen || !t.blockIsText {
+			frames = append(frames, t.openBlock(`{"type":"text","text":""}`))
+			t.blockIsText = true
+		}
+		delta := fmt.Sprintf(`{"type":"content_block_delta","index":%d,"delta":{"type":"text_delta","text":%s}}`,
+			t.blockIndex, jsonString(part.Get("text").String()))
+		frames = append(frames, t.sseFrame("content_block_delta", delta))
+	case part.Get("functionCall").Exists():
+		t.hasToolUse = true
+		if t.blockOpen {
+			frames = append(frames, t.sseFrame("content_block_stop", fmt.Sprintf(`{"type":"content_block_stop","index":%d}`, t.blockIndex)))
+			t.blockOpen = false
+		}
+		fc := part.Get("functionCall")
+		start := fmt.Sprintf(`{"type":"tool_use","id":%s,"name":%s,"input":{}}`,
+			jsonString(fc.Get("id").String()), jsonString(fc.Get("name").String()))
+		frames = append(frames, t.openBlock(start))
+		t.blockIsText = false
+
+		args := fc.Get("args")
+		argsJSON := `{}`
+		if args.Exists() {
+			argsJSON = args.Raw
+		}
+		delta := fmt.Sprintf(`{"type":"content_block_delta","index":%d,"delta":{"type":"input_json_delta","partial_json":%s}}`,
+			t.blockIndex, jsonString(argsJSON))
+		frames = append(frames, t.sseFrame("content_block_delta", delta))
+
+		frames = append(frames, t.sseFrame("content_block_stop", fmt.Sprintf(`{"type":"content_block_stop","index":%d}`, t.blockIndex)))
+		t.blockOpen = false
+	}
+
+	return frames
+}
+
+func (t *StreamTranslator) openBlock(contentBlock string) string {
+	t.blockIndex++
+	t.blockOpen = true
+	payload := fmt.Sprintf(`{"type":"content_block_start","index":%d,"content_block":%s}`, t.blockIndex, contentBlock)
+	return t.sseFrame("content_block_start", payload)
+}
+
+func (t *StreamTranslator) messageStartPayload() string {
+	return fmt.Sprintf(`{"type":"message_start","message":{"type":"message","id":%s,"role":"assistant","model":%s,"content":[]}}`,
+		jsonString(t.messageID), jsonString(t.modelName))
+}
+
+func (t *StreamTranslator) sseFrame(event, data string) string {
+	return fmt.Sprintf("event: %s\ndata: %s\n\n", event, data)
+}
+
+func jsonString(s string) string {
+	encoded, _ := sjson.SetBytes([]byte(`{}`), "v", s)
+	return gjson.GetBytes(encoded, "v").Raw
+}
@@ -0,0 +1,152 @@
+package messages
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertAntigravityResponseToAnthropic_Text(t *testing.T) {
+	inputJSON := []byte(`{
+		"candidates": [{"content": {"parts": [{"text": "hi there"}]}, "finishReason": "STOP"}],
+		"usageMetadata": {"promptTokenCount": 5, "candidatesTokenCount": 3}
+	}`)
+
+	output := ConvertAntigravityResponseToAnthropic("gemini-2.5-pro", inputJSON)
+	outputStr := string(output)
+
+	if !strings.HasPrefix(gjson.Get(outputStr, "id").String(), "msg_") {
+		t.Errorf("expected top-level id to start with 'msg_', got %q", gjson.Get(outputStr, "id").String())
+	}
+	if gjson.Get(outputStr, "content.0.text").String() != "hi there" {
+		t.Errorf("expected first content block text 'hi there'")
+	}
+	if gjson.Get(outputStr, "stop_reason").String() != "end_turn" {
+		t.Errorf("expected stop_reason 'end_turn', got %q", gjson.Get(outputStr, "stop_reason").String())
+	}
+	if gjson.Get(outputStr, "usage.input_tokens").Int() != 5 {
+		t.Errorf("expected usage.input_tokens 5")
+	}
+}
+
+func TestConvertAntigravityResponseToAnthropic_ToolUseStopReason(t *testing.T) {
+	inputJSON := []byte(`{
+		"candidates": [{"content": {"parts": [{"functionCall": {"id": "call_1", "name": "Shell", "args": {"command": "ls"}}}]}, "finishReason": "STOP"}]
+	}`)
+
+	output := ConvertAntigravityResponseToAnthropic("gemini-2.5-pro", inputJSON)
+	outputStr := string(output)
+
+	if gjson.Get(outputStr, "stop_reason").String() != "tool_use" {
+		t.Errorf("expected stop_reason 'tool_use', got %q", gjson.Get(outputStr, "stop_reason").String())
+	}
+	block := gjson.Get(outputStr, "content.0")
+	if block.Get("type").String() != "tool_use" || block.Get("name").String() != "Shell" {
+		t.Errorf("expected a tool_use block for 'Shell', got %s", block.Raw)
+	}
+}
+
+func TestConvertAntigravityResponseToAnthropic_IDsAreUnique(t *testing.T) {
+	inputJSON := []byte(`{"candidates": [{"content": {"parts": [{"text": "hi"}]}}]}`)
+
+	first := gjson.GetBytes(ConvertAntigravityResponseToAnthropic("gemini-2.5-pro", inputJSON), "id").String()
+	second := gjson.GetBytes(ConvertAntigravityResponseToAnthropic("gemini-2.5-pro", inputJSON), "id").String()
+	if first == second {
+		t.Errorf("expected each response to get its own id, got %q twice", first)
+	}
+}
+
+func TestStreamTranslator_TextTurn(t *testing.T) {
+	st := NewStreamTranslator("gemini-2.5-pro")
+
+	frames := st.Feed([]byte(`{"candidates":[{"content":{"parts":[{"text":"hi"}]}}]}`))
+	if len(frames) != 3 {
+		t.Fatalf("expected message_start + content_block_start + content_block_delta frames, got %d: %v", len(frames), frames)
+	}
+	if !strings.Contains(frames[0], "event: message_start") {
+		t.Errorf("expected first frame to be message_start, got %q", frames[0])
+	}
+	if id := gjson.Get(frames[0], "message.id").String(); !strings.HasPrefix(id, "msg_") {
+		t.Errorf("expected message_start payload id to start with 'msg_', got %q", id)
+	}
+
+	frames = st.Feed([]byte(`{"candidates":[{"content":{"parts":[{"text":" there"}]},"finishReason":"STOP"}]}`))
+	var sawBlockStop, sawMessageDelta bool
+	for _, f := range frames {
+		if strings.Contains(f, "event: content_block_stop") {
+			sawBlockStop = true
+		}
+		if strings.Contains(f, "event: message_delta") {
+			sawMessageDelta = true
+		}
+	}
+	if !sawBlockStop {
+		t.Error("expected a content_block_stop frame once finishReason arrived")
+	}
+	if !sawMessageDelta {
+		t.Error("expected a message_delta frame carrying stop_reason")
+	}
+
+	stop := st.Close()
+	if !strings.Contains(stop, "event: message_stop") {
+		t.Errorf("expected Close to emit message_stop, got %q", stop)
+	}
+}
+
+func TestStreamTranslator_ToolUseOpensAndClosesOwnBlock(t *testing.T) {
+	st := NewStreamTranslator("gemini-2.5-pro")
+
+	st.Feed([]byte(`{"candidates":[{"content":{"parts":[{"text":"thinking"}]}}]}`))
+	frames := st.Feed([]byte(`{"candidates":[{"content":{"parts":[{"functionCall":{"id":"call_1","name":"Shell","args":{"command":"ls"}}}]},"finishReason":"STOP"}]}`))
+
+	var blockStarts, blockStops int
+	var sawToolUseStart bool
+	for _, f := range frames {
+		if strings.Contains(f, "event: content_block_start") {
+			blockStarts++
+			if strings.Contains(f, `"type":"tool_use"`) {
+				sawToolUseStart = true
+			}
+		}
+		if strings.Contains(f, "event: content_block_stop") {
+			blockStops++
+		}
+	}
+	if !sawToolUseStart {
+		t.Error("expected a tool_use content_block_start frame")
+	}
+	// The open text block from the first Feed must be closed before the
+	// tool_use block opens, and the tool_use block closes itself immediately
+	// (Gemini sends a functionCall whole, not incrementally).
+	if blockStarts != 1 {
+		t.Errorf("expected 1 content_block_start frame (tool_use) in this Feed, got %d", blockStarts)
+	}
+	if blockStops != 2 {
+		t.Errorf("expected 2 content_block_stop frames (text closed early, tool_use closed immediately), got %d", blockStops)
+	}
+
+	payload := strings.Join(frames, "")
+	if !strings.Contains(payload, `"stop_reason":"tool_use"`) {
+		t.Errorf("expected message_delta stop_reason 'tool_use' once a functionCall was seen, got %q", payload)
+	}
+}
+
+func TestStreamTranslator_MessageIDStableAcrossFeeds(t *testing.T) {
+	st := NewStreamTranslator("gemini-2.5-pro")
+
+	frames := st.Feed([]byte(`{"candidates":[{"content":{"parts":[{"text":"a"}]}}]}`))
+	id := gjson.Get(frames[0], "message.id").String()
+
+	// A second Feed on the same translator must not re-emit message_start
+	// (and therefore not mint a second id).
+	more := st.Feed([]byte(`{"candidates":[{"content":{"parts":[{"text":"b"}]}}]}`))
+	for _, f := range more {
+		if strings.Contains(f, "event: message_start") {
+			t.Errorf("expected message_start to be emitted only once, got a second: %q", f)
+		}
+	}
+	if id == "" {
+		t.Fatal("expected a non-empty message id from the first message_start")
+	}
+}
@@ -0,0 +1,214 @@
+// Package geminiparts builds the Gemini `contents`/`tools` JSON fragments
+// shared by every Antigravity request translator (OpenAI chat-completions,
+// native Anthropic Messages), so the two client formats can't quietly drift
+// apart as more of them are added.
+package geminiparts
+
+import (
+	"context"
+	"encoding/base64"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/mediafetch"
+)
+
+// ThoughtSignature is attached to every functionCall part a translator
+// synthesizes from a client-supplied tool call. The Gemini CLI backend
+// expects a thoughtSignature on function call parts that originate outside
+// of a model turn it produced itself; without it, multi-turn tool
+// conversations are rejected as malformed.
+const ThoughtSignature = "context_engineering_thought_signature"
+
+// TextPart builds a Gemini `text` part.
+func TextPart(text string) []byte {
+	part, _ := sjson.SetBytes([]byte(`{}`), "text", text)
+	return part
+}
+
+// FunctionCallPart builds a Gemini `functionCall` part from a client-supplied
+// tool call, stamping it with ThoughtSignature.
+func FunctionCallPart(id, name string, args gjson.Result) []byte {
+	part := []byte(`{}`)
+	part, _ = sjson.SetBytes(part, "functionCall.id", id)
+	part, _ = sjson.SetBytes(part, "functionCall.name", name)
+	if args.Exists() && args.Raw != "" {
+		part, _ = sjson.SetRawBytes(part, "functionCall.args", []byte(args.Raw))
+	} else {
+		part, _ = sjson.SetRawBytes(part, "functionCall.args", []byte(`{}`))
+	}
+	part, _ = sjson.SetBytes(part, "thoughtSignature", ThoughtSignature)
+	return part
+}
+
+// FunctionResponsePart builds a Gemini `functionResponse` part from a
+// client-supplied tool result. content may be a plain string, an array of
+// Claude-style text blocks, or absent.
+func FunctionResponsePart(id, name string, content gjson.Result) []byte {
+	part := []byte(`{}`)
+	part, _ = sjson.SetBytes(part, "functionResponse.id", id)
+	part, _ = sjson.SetBytes(part, "functionResponse.name", name)
+
+	switch {
+	case content.Type == gjson.String:
+		part, _ = sjson.SetBytes(part, "functionResponse.response.content", content.String())
+	case content.IsArray():
+		var text string
+		content.ForEach(func(_, block gjson.Result) bool {
+			if block.Get("type").String() == "text" {
+				text += block.Get("text").String()
+			}
+			return true
+		})
+		part, _ = sjson.SetBytes(part, "functionResponse.response.content", text)
+	default:
+		part, _ = sjson.SetRawBytes(part, "functionResponse.response.content", []byte(`null`))
+	}
+	return part
+}
+
+// AppendContent appends a `{role, parts}` content entry to a Gemini
+// `contents` array.
+func AppendContent(contents []byte, role string, parts [][]byte) []byte {
+	entry := []byte(`{}`)
+	entry, _ = sjson.SetBytes(entry, "role", role)
+	entry, _ = sjson.SetRawBytes(entry, "parts", []byte(`[]`))
+	for _, p := range parts {
+		entry, _ = sjson.SetRawBytes(entry, "parts.-1", p)
+	}
+	updated, _ := sjson.SetRawBytes(contents, "-1", entry)
+	return updated
+}
+
+// BuildToolsPayload converts a mixed OpenAI/Claude tools array (`function.*`
+// or `name`/`description`/`input_schema`) into a single Gemini tool entry
+// carrying a functionDeclarations array. Tools that don't carry a usable
+// schema are skipped rather than emitted with a fabricated one.
+func BuildToolsPayload(tools gjson.Result) []byte {
+	var decls [][]byte
+
+	tools.ForEach(func(_, tool gjson.Result) bool {
+		var name, description string
+		var schema gjson.Result
+
+		if fn := tool.Get("function"); fn.Exists() {
+			name = fn.Get("name").String()
+			description = fn.Get("description").String()
+			schema = fn.Get("parameters")
+		} else {
+			name = tool.Get("name").String()
+			description = tool.Get("description").String()
+			schema = tool.Get("input_schema")
+		}
+
+		if !schema.Exists() {
+			return true
+		}
+
+		decl := []byte(`{}`)
+		decl, _ = sjson.SetBytes(decl, "name", name)
+		decl, _ = sjson.SetBytes(decl, "description", description)
+		decl, _ = sjson.SetRawBytes(decl, "parametersJsonSchema", []byte(schema.Raw))
+		decls = append(decls, decl)
+		return true
+	})
+
+	if len(decls) == 0 {
+		return nil
+	}
+
+	toolsOut := []byte(`[{"functionDeclarations":[]}]`)
+	for _, decl := range decls {
+		toolsOut, _ = sjson.SetRawBytes(toolsOut, "0.functionDeclarations.-1", decl)
+	}
+	return toolsOut
+}
+
+// InlineData builds a Gemini `inlineData` part from an already-decoded
+// MIME type and base64 payload.
+func InlineData(mimeType, base64Data string) []byte {
+	part := []byte(`{}`)
+	part, _ = sjson.SetBytes(part, "inlineData.mimeType", mimeType)
+	part, _ = sjson.SetBytes(part, "inlineData.data", base64Data)
+	return part
+}
+
+// FetchInlineData fetches url and wraps its body as an InlineData part,
+// returning nil if the fetch fails so callers can skip the block rather than
+// fail the whole translation over one bad URL.
+func FetchInlineData(ctx context.Context, url string) []byte {
+	mimeType, data, err := mediafetch.Fetch(ctx, url)
+	if err != nil {
+		return nil
+	}
+	return InlineData(mimeType, base64.StdEncoding.EncodeToString(data))
+}
+
+// MaxRemoteMediaFetchesPerRequest bounds how many distinct remote media URLs
+// a single request's RemoteMediaFetcher will fetch. Without a cap, a message
+// with N remote image/document blocks could block request translation for up
+// to mediafetch's 30s timeout times N.
+const MaxRemoteMediaFetchesPerRequest = 8
+
+// RemoteMediaFetcher collects the remote media URLs a request's content
+// blocks reference, then fetches all of them concurrently in one Fetch call
+// instead of one-at-a-time inside each block's translation — so a message
+// with several remote blocks costs roughly one fetch's latency, not the sum.
+// Callers: Want every URL up front (deduplicated, capped at
+// MaxRemoteMediaFetchesPerRequest), then call Fetch once before building
+// parts.
+type RemoteMediaFetcher struct {
+	urls []string
+	seen map[string]bool
+}
+
+// NewRemoteMediaFetcher creates an empty fetcher for a single request.
+func NewRemoteMediaFetcher() *RemoteMediaFetcher {
+	return &RemoteMediaFetcher{seen: make(map[string]bool)}
+}
+
+// Want registers url as needed by some content block. Duplicate URLs within
+// a request are only fetched once; URLs beyond MaxRemoteMediaFetchesPerRequest
+// are dropped, so the corresponding block will simply get no inlineData part
+// (mirroring FetchInlineData's existing fail-open-to-nil behavior).
+func (f *RemoteMediaFetcher) Want(url string) {
+	if url == "" || f.seen[url] {
+		return
+	}
+	if len(f.urls) >= MaxRemoteMediaFetchesPerRequest {
+		return
+	}
+	f.seen[url] = true
+	f.urls = append(f.urls, url)
+}
+
+// Fetch runs every wanted URL concurrently and returns the resulting
+// InlineData parts keyed by URL. A URL that failed to fetch is simply absent
+// from the result.
+func (f *RemoteMediaFetcher) Fetch(ctx context.Context) map[string][]byte {
+	results := make(map[string][]byte, len(f.urls))
+	if len(f.urls) == 0 {
+		return results
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, url := range f.urls {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			part := FetchInlineData(ctx, url)
+			if part == nil {
+				return
+			}
+			mu.Lock()
+			results[url] = part
+			mu.Unlock()
+		}(url)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,124 @@
+// Package mediafetch retrieves remote media (images, PDFs) referenced by URL
+// in a chat request so they can be inlined into a Gemini `inlineData` part,
+// shared by the OpenAI and Anthropic translators.
+package mediafetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxBytes bounds how much of a remote media response is read, so a single
+// request can't be used to pull an unbounded amount of data through the
+// proxy.
+const MaxBytes = 20 << 20 // 20 MiB
+
+// timeout bounds how long Fetch will wait on a slow or non-responding
+// remote server; without it a client-supplied image/document URL could hang
+// the translating goroutine indefinitely.
+const timeout = 30 * time.Second
+
+// Client is the http.Client Fetch sends requests through. It rejects
+// connections to loopback, private, and link-local addresses at dial time,
+// so a client-supplied image/document URL can't be used to make the proxy
+// fetch internal services (e.g. a cloud metadata endpoint) on its behalf.
+// Checking at dial time rather than pre-resolving the host guards against
+// DNS rebinding between the check and the connection.
+//
+// Tests that need to fetch from an httptest server (which necessarily
+// listens on loopback) may swap this for a client without the restriction;
+// production code must never do so.
+var Client = &http.Client{
+	Timeout: timeout,
+	Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				if isDisallowedTarget(ip.IP) {
+					return nil, fmt.Errorf("mediafetch: refusing to fetch from disallowed address %s", ip.IP)
+				}
+			}
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+		},
+	},
+}
+
+// isDisallowedTarget reports whether ip falls in a range that should never
+// be reachable from a server-side fetch of a client-supplied URL: loopback,
+// private, link-local (including the link-local multicast and the cloud
+// metadata address ranges it covers), and unspecified addresses.
+func isDisallowedTarget(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// Fetch downloads url and returns its bytes along with a best-effort MIME
+// type: the response's Content-Type header if present and not a generic
+// octet-stream, otherwise a sniff of the body via http.DetectContentType.
+func Fetch(ctx context.Context, url string) (mimeType string, data []byte, err error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", nil, fmt.Errorf("mediafetch: unsupported URL scheme in %q", url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("mediafetch: building request failed: %w", err)
+	}
+
+	resp, err := Client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("mediafetch: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("mediafetch: unexpected status %d fetching %s", resp.StatusCode, url)
+	}
+
+	data, err = io.ReadAll(io.LimitReader(resp.Body, MaxBytes+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("mediafetch: reading body failed: %w", err)
+	}
+	if len(data) > MaxBytes {
+		return "", nil, fmt.Errorf("mediafetch: %s exceeds the %d byte limit", url, MaxBytes)
+	}
+
+	mimeType = resp.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	return mimeType, data, nil
+}
+
+// ParseDataURI splits a `data:<mime>;base64,<data>` URI into its MIME type
+// and base64 payload. It reports ok=false for anything else (including
+// non-base64 data URIs, which clients in this ecosystem don't send).
+func ParseDataURI(uri string) (mimeType, base64Data string, ok bool) {
+	const prefix = "data:"
+	if !strings.HasPrefix(uri, prefix) {
+		return "", "", false
+	}
+	rest := uri[len(prefix):]
+
+	meta, data, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+	mimeType, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !isBase64 {
+		return "", "", false
+	}
+	return mimeType, data, true
+}
@@ -0,0 +1,68 @@
+package chat_completions
+
+import (
+	"github.com/tidwall/gjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/geminiparts"
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/mediafetch"
+)
+
+// wantMedia registers any remote URL an `image_url`/`image`/`document` block
+// references with fetcher, so it can be fetched concurrently with the rest of
+// the request's media blocks instead of serially inside mediaPart.
+func wantMedia(block gjson.Result, fetcher *geminiparts.RemoteMediaFetcher) {
+	switch block.Get("type").String() {
+	case "image_url":
+		wantMediaURL(block.Get("image_url.url").String(), fetcher)
+	case "image", "document":
+		wantMediaSource(block.Get("source"), fetcher)
+	}
+}
+
+func wantMediaURL(url string, fetcher *geminiparts.RemoteMediaFetcher) {
+	if _, _, ok := mediafetch.ParseDataURI(url); ok {
+		return
+	}
+	fetcher.Want(url)
+}
+
+func wantMediaSource(source gjson.Result, fetcher *geminiparts.RemoteMediaFetcher) {
+	if source.Get("type").String() == "url" {
+		wantMediaURL(source.Get("url").String(), fetcher)
+	}
+}
+
+// mediaPart converts an OpenAI `image_url` block or a Claude `image`/
+// `document` block into a Gemini inlineData part. fetched is the result of a
+// RemoteMediaFetcher.Fetch call made once for the whole request; mediaPart
+// looks a remote URL up there rather than fetching it itself. It returns nil
+// if the block doesn't carry media this translator knows how to handle, or
+// if a remote fetch failed or was dropped for exceeding the request's cap.
+func mediaPart(block gjson.Result, fetched map[string][]byte) []byte {
+	switch block.Get("type").String() {
+	case "image_url":
+		return inlineDataFromURL(block.Get("image_url.url").String(), fetched)
+	case "image", "document":
+		return inlineDataFromSource(block.Get("source"), fetched)
+	default:
+		return nil
+	}
+}
+
+func inlineDataFromURL(url string, fetched map[string][]byte) []byte {
+	if mimeType, data, ok := mediafetch.ParseDataURI(url); ok {
+		return geminiparts.InlineData(mimeType, data)
+	}
+	return fetched[url]
+}
+
+func inlineDataFromSource(source gjson.Result, fetched map[string][]byte) []byte {
+	switch source.Get("type").String() {
+	case "base64":
+		return geminiparts.InlineData(source.Get("media_type").String(), source.Get("data").String())
+	case "url":
+		return inlineDataFromURL(source.Get("url").String(), fetched)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,242 @@
+package chat_completions
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/geminiparts"
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/mediafetch"
+)
+
+func TestConvertOpenAIRequestToAntigravity_ImageURLBase64(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "text", "text": "What is this?"},
+					{"type": "image_url", "image_url": {"url": "data:image/png;base64,abc123"}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertOpenAIRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	parts := gjson.Get(outputStr, "request.contents.0.parts")
+	if !parts.IsArray() || len(parts.Array()) != 2 {
+		t.Fatalf("expected 2 parts (text + image), got %d", len(parts.Array()))
+	}
+	image := parts.Array()[1]
+	if image.Get("inlineData.mimeType").String() != "image/png" {
+		t.Errorf("expected inlineData.mimeType 'image/png', got '%s'", image.Get("inlineData.mimeType").String())
+	}
+	if image.Get("inlineData.data").String() != "abc123" {
+		t.Errorf("expected inlineData.data 'abc123', got '%s'", image.Get("inlineData.data").String())
+	}
+}
+
+func TestConvertOpenAIRequestToAntigravity_ClaudeImageBlockBase64(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "image", "source": {"type": "base64", "media_type": "image/jpeg", "data": "xyz789"}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertOpenAIRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	image := gjson.Get(outputStr, "request.contents.0.parts.0")
+	if image.Get("inlineData.mimeType").String() != "image/jpeg" {
+		t.Errorf("expected inlineData.mimeType 'image/jpeg', got '%s'", image.Get("inlineData.mimeType").String())
+	}
+	if image.Get("inlineData.data").String() != "xyz789" {
+		t.Errorf("expected inlineData.data 'xyz789', got '%s'", image.Get("inlineData.data").String())
+	}
+}
+
+func TestConvertOpenAIRequestToAntigravity_RemoteImageURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("fake-png-bytes"))
+	}))
+	defer server.Close()
+
+	// mediafetch.Client refuses loopback targets in production (an SSRF
+	// guard), but httptest.NewServer necessarily listens on loopback; swap
+	// in a plain client for the duration of this test.
+	previousClient := mediafetch.Client
+	mediafetch.Client = &http.Client{}
+	defer func() { mediafetch.Client = previousClient }()
+
+	inputJSON := []byte(fmt.Sprintf(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "image_url", "image_url": {"url": "%s"}}
+				]
+			}
+		]
+	}`, server.URL))
+
+	output := ConvertOpenAIRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	image := gjson.Get(outputStr, "request.contents.0.parts.0")
+	if image.Get("inlineData.mimeType").String() != "image/png" {
+		t.Errorf("expected inlineData.mimeType 'image/png', got '%s'", image.Get("inlineData.mimeType").String())
+	}
+	if image.Get("inlineData.data").String() == "" {
+		t.Error("expected inlineData.data to be populated from the fetched body")
+	}
+}
+
+func TestConvertOpenAIRequestToAntigravity_MultiImageMessage(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "image_url", "image_url": {"url": "data:image/png;base64,first"}},
+					{"type": "image_url", "image_url": {"url": "data:image/png;base64,second"}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertOpenAIRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	parts := gjson.Get(outputStr, "request.contents.0.parts")
+	if !parts.IsArray() || len(parts.Array()) != 2 {
+		t.Fatalf("expected 2 image parts, got %d", len(parts.Array()))
+	}
+	if parts.Array()[0].Get("inlineData.data").String() != "first" {
+		t.Errorf("expected first image data 'first'")
+	}
+	if parts.Array()[1].Get("inlineData.data").String() != "second" {
+		t.Errorf("expected second image data 'second'")
+	}
+}
+
+// TestConvertOpenAIRequestToAntigravity_RemoteImagesFetchedConcurrently
+// drives a message with several slow remote image blocks and asserts the
+// total translation time is close to one fetch's latency, not N of them
+// serially — guarding the fix that moved remote media fetching out of each
+// block's synchronous, one-at-a-time path.
+func TestConvertOpenAIRequestToAntigravity_RemoteImagesFetchedConcurrently(t *testing.T) {
+	const numImages = 4
+	const fetchDelay = 150 * time.Millisecond
+
+	var inFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&inFlight, 1)
+		time.Sleep(fetchDelay)
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("fake-png-bytes"))
+	}))
+	defer server.Close()
+
+	previousClient := mediafetch.Client
+	mediafetch.Client = &http.Client{}
+	defer func() { mediafetch.Client = previousClient }()
+
+	var blocks []string
+	for i := 0; i < numImages; i++ {
+		blocks = append(blocks, fmt.Sprintf(`{"type":"image_url","image_url":{"url":%q}}`, fmt.Sprintf("%s/%d", server.URL, i)))
+	}
+	inputJSON := []byte(fmt.Sprintf(`{
+		"model": "gemini-2.5-pro",
+		"messages": [{"role": "user", "content": [%s]}]
+	}`, strings.Join(blocks, ",")))
+
+	start := time.Now()
+	output := ConvertOpenAIRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	elapsed := time.Since(start)
+
+	if elapsed >= fetchDelay*numImages {
+		t.Errorf("expected concurrent fetches to take well under %v (serial time), took %v", fetchDelay*numImages, elapsed)
+	}
+
+	parts := gjson.GetBytes(output, "request.contents.0.parts")
+	if !parts.IsArray() || len(parts.Array()) != numImages {
+		t.Fatalf("expected %d image parts, got %d", numImages, len(parts.Array()))
+	}
+}
+
+// TestConvertOpenAIRequestToAntigravity_RemoteImagesCappedPerRequest drives a
+// message with more remote image blocks than
+// geminiparts.MaxRemoteMediaFetchesPerRequest and asserts the blocks beyond
+// the cap are simply dropped (no inlineData part), not fetched anyway.
+func TestConvertOpenAIRequestToAntigravity_RemoteImagesCappedPerRequest(t *testing.T) {
+	var fetchCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetchCount, 1)
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("fake-png-bytes"))
+	}))
+	defer server.Close()
+
+	previousClient := mediafetch.Client
+	mediafetch.Client = &http.Client{}
+	defer func() { mediafetch.Client = previousClient }()
+
+	numImages := geminiparts.MaxRemoteMediaFetchesPerRequest + 3
+	var blocks []string
+	for i := 0; i < numImages; i++ {
+		blocks = append(blocks, fmt.Sprintf(`{"type":"image_url","image_url":{"url":%q}}`, fmt.Sprintf("%s/%d", server.URL, i)))
+	}
+	inputJSON := []byte(fmt.Sprintf(`{
+		"model": "gemini-2.5-pro",
+		"messages": [{"role": "user", "content": [%s]}]
+	}`, strings.Join(blocks, ",")))
+
+	output := ConvertOpenAIRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+
+	if got := atomic.LoadInt32(&fetchCount); got != int32(geminiparts.MaxRemoteMediaFetchesPerRequest) {
+		t.Errorf("expected exactly %d fetches (the cap), got %d", geminiparts.MaxRemoteMediaFetchesPerRequest, got)
+	}
+	if got := len(gjson.GetBytes(output, "request.contents.0.parts").Array()); got != geminiparts.MaxRemoteMediaFetchesPerRequest {
+		t.Errorf("expected %d inlineData parts (blocks past the cap dropped), got %d", geminiparts.MaxRemoteMediaFetchesPerRequest, got)
+	}
+}
+
+func TestConvertOpenAIRequestToAntigravity_DocumentBlock(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "document", "source": {"type": "base64", "media_type": "application/pdf", "data": "pdfdata"}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertOpenAIRequestToAntigravity("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	doc := gjson.Get(outputStr, "request.contents.0.parts.0")
+	if doc.Get("inlineData.mimeType").String() != "application/pdf" {
+		t.Errorf("expected inlineData.mimeType 'application/pdf', got '%s'", doc.Get("inlineData.mimeType").String())
+	}
+}
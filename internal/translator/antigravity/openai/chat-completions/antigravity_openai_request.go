@@ -0,0 +1,197 @@
+// Package chat_completions provides request translation functionality for OpenAI to Gemini CLI API compatibility.
+package chat_completions
+
+import (
+	"context"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/safzanpirani/CLIProxyAPIPlus/internal/translator/antigravity/geminiparts"
+)
+
+// geminiCLIFunctionThoughtSignature is the sentinel geminiparts.FunctionCallPart
+// stamps onto synthesized functionCall parts, aliased here so existing tests
+// can keep asserting on it without reaching into another package.
+const geminiCLIFunctionThoughtSignature = geminiparts.ThoughtSignature
+
+// ConvertOpenAIRequestToAntigravity translates an OpenAI-shaped chat completion
+// request (including the Claude/Cursor tool_use, tool_result and input_schema
+// conventions many clients reuse) into the Antigravity/Gemini CLI request
+// envelope. The stream flag is currently informational and is threaded through
+// so callers can branch on it without re-parsing the request.
+func ConvertOpenAIRequestToAntigravity(modelName string, rawJSON []byte, stream bool) []byte {
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	out, _ = sjson.SetBytes(out, "stream", stream)
+
+	messages := gjson.GetBytes(rawJSON, "messages")
+	contents := buildContentsFromMessages(messages, prefetchMedia(messages))
+	out, _ = sjson.SetRawBytes(out, "request.contents", contents)
+
+	if toolsResult := gjson.GetBytes(rawJSON, "tools"); toolsResult.IsArray() {
+		if toolsOut := geminiparts.BuildToolsPayload(toolsResult); toolsOut != nil {
+			out, _ = sjson.SetRawBytes(out, "request.tools", toolsOut)
+		}
+	}
+
+	if system := gjson.GetBytes(rawJSON, "system"); system.Exists() {
+		out, _ = sjson.SetBytes(out, "request.systemInstruction.parts.0.text", system.String())
+	}
+
+	return out
+}
+
+// prefetchMedia walks every message's content blocks once up front and
+// fetches all the remote media URLs they reference concurrently (capped at
+// geminiparts.MaxRemoteMediaFetchesPerRequest), so a message carrying several
+// remote image/document blocks costs roughly one fetch's latency rather than
+// one mediafetch timeout per block.
+func prefetchMedia(messages gjson.Result) map[string][]byte {
+	fetcher := geminiparts.NewRemoteMediaFetcher()
+	messages.ForEach(func(_, msg gjson.Result) bool {
+		if content := msg.Get("content"); content.IsArray() {
+			content.ForEach(func(_, block gjson.Result) bool {
+				wantMedia(block, fetcher)
+				return true
+			})
+		}
+		return true
+	})
+	return fetcher.Fetch(context.Background())
+}
+
+// buildContentsFromMessages walks the OpenAI message list in order, folding
+// Claude-style content blocks (text, tool_use, tool_result) and OpenAI-style
+// tool_calls/tool messages into Gemini `contents` entries. fetched is the
+// result of a prior prefetchMedia call, consulted for any remote media block
+// instead of fetching it inline.
+func buildContentsFromMessages(messages gjson.Result, fetched map[string][]byte) []byte {
+	contents := []byte(`[]`)
+	// toolCallNames remembers which function a tool_call_id belongs to so a
+	// later role:"tool" (or tool_result) message can be translated into a
+	// named functionResponse even though OpenAI's wire format drops the name.
+	toolCallNames := map[string]string{}
+
+	messages.ForEach(func(_, msg gjson.Result) bool {
+		role := msg.Get("role").String()
+
+		switch role {
+		case "system":
+			// role:"system" messages are skipped so they don't end up as a
+			// content; they are not carried into systemInstruction either,
+			// which the caller sets only from the top-level `system` field.
+			return true
+		case "assistant":
+			parts := buildAssistantParts(msg, toolCallNames, fetched)
+			if len(parts) > 0 {
+				contents = geminiparts.AppendContent(contents, "model", parts)
+			}
+		case "tool":
+			parts := [][]byte{geminiparts.FunctionResponsePart(
+				msg.Get("tool_call_id").String(),
+				toolCallNames[msg.Get("tool_call_id").String()],
+				msg.Get("content"),
+			)}
+			contents = geminiparts.AppendContent(contents, "user", parts)
+		default: // "user" and anything else defaults to the user role
+			parts := buildUserParts(msg, toolCallNames, fetched)
+			if len(parts) > 0 {
+				contents = geminiparts.AppendContent(contents, "user", parts)
+			}
+		}
+		return true
+	})
+
+	return contents
+}
+
+// buildUserParts handles plain string content as well as Claude-style content
+// arrays (text and tool_result blocks) found on user messages.
+func buildUserParts(msg gjson.Result, toolCallNames map[string]string, fetched map[string][]byte) [][]byte {
+	content := msg.Get("content")
+	if content.Type == gjson.String {
+		if content.String() == "" {
+			return nil
+		}
+		return [][]byte{geminiparts.TextPart(content.String())}
+	}
+
+	if !content.IsArray() {
+		return nil
+	}
+
+	var parts [][]byte
+	content.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			if text := block.Get("text").String(); text != "" {
+				parts = append(parts, geminiparts.TextPart(text))
+			}
+		case "tool_result":
+			id := block.Get("tool_use_id").String()
+			parts = append(parts, geminiparts.FunctionResponsePart(id, toolCallNames[id], block.Get("content")))
+		case "image_url", "image", "document":
+			if part := mediaPart(block, fetched); part != nil {
+				parts = append(parts, part)
+			}
+		}
+		return true
+	})
+	return parts
+}
+
+// buildAssistantParts handles Claude-style assistant content arrays
+// (text/tool_use) as well as OpenAI-style string content plus a sibling
+// tool_calls array.
+func buildAssistantParts(msg gjson.Result, toolCallNames map[string]string, fetched map[string][]byte) [][]byte {
+	var parts [][]byte
+
+	content := msg.Get("content")
+	switch {
+	case content.Type == gjson.String:
+		if text := content.String(); text != "" {
+			parts = append(parts, geminiparts.TextPart(text))
+		}
+	case content.IsArray():
+		content.ForEach(func(_, block gjson.Result) bool {
+			switch block.Get("type").String() {
+			case "text":
+				if text := block.Get("text").String(); text != "" {
+					parts = append(parts, geminiparts.TextPart(text))
+				}
+			case "tool_use":
+				id := block.Get("id").String()
+				name := block.Get("name").String()
+				toolCallNames[id] = name
+				parts = append(parts, geminiparts.FunctionCallPart(id, name, block.Get("input")))
+			case "image_url", "image", "document":
+				if part := mediaPart(block, fetched); part != nil {
+					parts = append(parts, part)
+				}
+			}
+			return true
+		})
+	}
+
+	msg.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		id := call.Get("id").String()
+		name := call.Get("function.name").String()
+		toolCallNames[id] = name
+		args := call.Get("function.arguments")
+		parts = append(parts, geminiparts.FunctionCallPart(id, name, parseArguments(args)))
+		return true
+	})
+
+	return parts
+}
+
+// parseArguments recovers the JSON object a client packed into a string
+// (OpenAI's tool_calls[].function.arguments convention) so it can be set as
+// raw JSON on the functionCall part.
+func parseArguments(args gjson.Result) gjson.Result {
+	if args.Type == gjson.String {
+		return gjson.Parse(args.String())
+	}
+	return args
+}
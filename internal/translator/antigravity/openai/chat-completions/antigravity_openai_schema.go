@@ -0,0 +1,62 @@
+package chat_completions
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIRequestToAntigravityWithSchema behaves like
+// ConvertOpenAIRequestToAntigravity but additionally honors OpenAI's
+// `response_format: {"type":"json_schema", ...}` and a `tool_choice` that
+// forces a specific function, mapping both onto Gemini's native constrained
+// decoding (`responseSchema`/`responseMimeType` and
+// `toolConfig.functionCallingConfig`). Callers whose upstream model doesn't
+// honor responseSchema should fall back to the `grammar` package to validate
+// (and, if needed, repair) the resulting text against the same schema.
+func ConvertOpenAIRequestToAntigravityWithSchema(modelName string, rawJSON []byte, stream bool) []byte {
+	out := ConvertOpenAIRequestToAntigravity(modelName, rawJSON, stream)
+
+	if schema := jsonSchemaFromResponseFormat(gjson.GetBytes(rawJSON, "response_format")); schema != nil {
+		out, _ = sjson.SetRawBytes(out, "request.generationConfig.responseSchema", schema)
+		out, _ = sjson.SetBytes(out, "request.generationConfig.responseMimeType", "application/json")
+	}
+
+	if toolConfig := forcedToolConfig(gjson.GetBytes(rawJSON, "tool_choice")); toolConfig != nil {
+		out, _ = sjson.SetRawBytes(out, "request.toolConfig", toolConfig)
+	}
+
+	return out
+}
+
+// jsonSchemaFromResponseFormat extracts the raw JSON Schema document from an
+// OpenAI `response_format: {"type":"json_schema","json_schema":{"schema":...}}`
+// object, or returns nil if response_format doesn't request structured output.
+func jsonSchemaFromResponseFormat(responseFormat gjson.Result) []byte {
+	if responseFormat.Get("type").String() != "json_schema" {
+		return nil
+	}
+	schema := responseFormat.Get("json_schema.schema")
+	if !schema.Exists() {
+		return nil
+	}
+	return []byte(schema.Raw)
+}
+
+// forcedToolConfig maps an OpenAI `tool_choice` that names a specific
+// function (`{"type":"function","function":{"name":"..."}}`) onto Gemini's
+// functionCallingConfig, mirroring how Anthropic's `tool_choice: {"type":
+// "tool", ...}` is handled by the Anthropic translator.
+func forcedToolConfig(toolChoice gjson.Result) []byte {
+	if toolChoice.Get("type").String() != "function" {
+		return nil
+	}
+	name := toolChoice.Get("function.name").String()
+	if name == "" {
+		return nil
+	}
+
+	cfg := []byte(`{}`)
+	cfg, _ = sjson.SetBytes(cfg, "functionCallingConfig.mode", "ANY")
+	cfg, _ = sjson.SetBytes(cfg, "functionCallingConfig.allowedFunctionNames.0", name)
+	return cfg
+}
@@ -0,0 +1,66 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToAntigravityWithSchema_ResponseFormat(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [{"role": "user", "content": "Give me JSON"}],
+		"response_format": {
+			"type": "json_schema",
+			"json_schema": {
+				"name": "Answer",
+				"schema": {"type": "object", "properties": {"answer": {"type": "string"}}, "required": ["answer"]}
+			}
+		}
+	}`)
+
+	output := ConvertOpenAIRequestToAntigravityWithSchema("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	if gjson.Get(outputStr, "request.generationConfig.responseMimeType").String() != "application/json" {
+		t.Errorf("expected responseMimeType 'application/json'")
+	}
+	if !gjson.Get(outputStr, "request.generationConfig.responseSchema.properties.answer").Exists() {
+		t.Error("expected responseSchema to carry the answer property")
+	}
+}
+
+func TestConvertOpenAIRequestToAntigravityWithSchema_ForcedToolChoice(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [{"role": "user", "content": "Run it"}],
+		"tool_choice": {"type": "function", "function": {"name": "Shell"}}
+	}`)
+
+	output := ConvertOpenAIRequestToAntigravityWithSchema("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	if gjson.Get(outputStr, "request.toolConfig.functionCallingConfig.mode").String() != "ANY" {
+		t.Errorf("expected functionCallingConfig.mode 'ANY'")
+	}
+	if gjson.Get(outputStr, "request.toolConfig.functionCallingConfig.allowedFunctionNames.0").String() != "Shell" {
+		t.Errorf("expected allowedFunctionNames.0 'Shell'")
+	}
+}
+
+func TestConvertOpenAIRequestToAntigravityWithSchema_NoStructuredOutputRequested(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [{"role": "user", "content": "Hello"}]
+	}`)
+
+	output := ConvertOpenAIRequestToAntigravityWithSchema("gemini-2.5-pro", inputJSON, false)
+	outputStr := string(output)
+
+	if gjson.Get(outputStr, "request.generationConfig.responseSchema").Exists() {
+		t.Error("expected no responseSchema when response_format is absent")
+	}
+	if gjson.Get(outputStr, "request.toolConfig").Exists() {
+		t.Error("expected no toolConfig when tool_choice doesn't force a function")
+	}
+}
@@ -0,0 +1,67 @@
+package grammar
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultMaxRepairAttempts bounds how many times Enforcer.EnsureJSON will ask
+// the model to repair its own output before giving up.
+const DefaultMaxRepairAttempts = 2
+
+// Regenerate re-runs the model with an appended repair prompt and returns the
+// new raw text output.
+type Regenerate func(ctx context.Context, repairPrompt string) (string, error)
+
+// Enforcer validates model output against a JSON Schema and, on failure,
+// drives a bounded repair loop: it asks the caller to regenerate the output
+// with a prompt describing the validation error, then re-validates.
+type Enforcer struct {
+	Schema            []byte
+	MaxRepairAttempts int
+}
+
+// NewEnforcer creates an Enforcer for the given JSON Schema.
+func NewEnforcer(schema []byte) *Enforcer {
+	return &Enforcer{Schema: schema, MaxRepairAttempts: DefaultMaxRepairAttempts}
+}
+
+// EnsureJSON validates text against the Enforcer's schema. If it fails,
+// regenerate is called with a repair prompt describing the mismatch, up to
+// MaxRepairAttempts times, until a valid result is produced or attempts are
+// exhausted.
+func (e *Enforcer) EnsureJSON(ctx context.Context, text string, regenerate Regenerate) (string, error) {
+	maxAttempts := e.MaxRepairAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxRepairAttempts
+	}
+
+	candidate := text
+	var lastErr error
+	for attempt := 0; attempt <= maxAttempts; attempt++ {
+		if err := ValidateAgainstSchema([]byte(candidate), e.Schema); err == nil {
+			return candidate, nil
+		} else {
+			lastErr = err
+		}
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		repaired, err := regenerate(ctx, repairPrompt(candidate, lastErr))
+		if err != nil {
+			return "", fmt.Errorf("grammar: repair attempt %d failed: %w", attempt+1, err)
+		}
+		candidate = repaired
+	}
+
+	return "", fmt.Errorf("grammar: output did not satisfy schema after %d repair attempt(s): %w", maxAttempts, lastErr)
+}
+
+func repairPrompt(previous string, validationErr error) string {
+	return fmt.Sprintf(
+		"Your previous response was not valid JSON for the required schema: %s\n\nPrevious response:\n%s\n\nReturn ONLY corrected JSON that satisfies the schema.",
+		validationErr, previous,
+	)
+}
@@ -0,0 +1,330 @@
+package grammar
+
+import "strings"
+
+// gbnfAccepts reports whether input is fully matched by the "root" rule of a
+// grammar string produced by SchemaToGBNF. It implements just enough of GBNF
+// (rule refs, literals, "|", concatenation, grouping, "?"/"*"/"+", and
+// bracketed char classes with ranges and escapes) to let
+// schema_to_gbnf_test.go assert on actual acceptance/rejection of candidate
+// documents instead of just checking that rule names appear in the grammar
+// text. A negated class like `[^...]` is read as a plain class that includes
+// `^`, and `.` is skipped, so the shared `string` rule is not matched the way
+// GBNF defines it.
+func gbnfAccepts(grammar, input string) bool {
+	rules := parseGBNFRules(grammar)
+	m := &gbnfMatcher{rules: rules, parsed: map[string]gbnfNode{}}
+	for _, end := range m.match(m.ruleNode("root"), input, 0) {
+		if end == len(input) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseGBNFRules(grammar string) map[string]string {
+	rules := map[string]string{}
+	for _, line := range strings.Split(grammar, "\n") {
+		name, body, found := strings.Cut(line, "::=")
+		if !found {
+			continue
+		}
+		rules[strings.TrimSpace(name)] = strings.TrimSpace(body)
+	}
+	return rules
+}
+
+type gbnfNode interface{}
+
+type gbnfLit struct{ s string }
+type gbnfClass struct{ chars string }
+type gbnfRef struct{ name string }
+type gbnfSeq struct{ items []gbnfNode }
+type gbnfAlt struct{ items []gbnfNode }
+type gbnfStar struct{ item gbnfNode }
+type gbnfPlus struct{ item gbnfNode }
+type gbnfOpt struct{ item gbnfNode }
+
+type gbnfMatcher struct {
+	rules  map[string]string
+	parsed map[string]gbnfNode
+}
+
+func (m *gbnfMatcher) ruleNode(name string) gbnfNode {
+	if node, ok := m.parsed[name]; ok {
+		return node
+	}
+	body, ok := m.rules[name]
+	if !ok {
+		// An identifier with no rule of its own is matched as the literal
+		// text of its name.
+		return gbnfLit{s: strings.Trim(name, `"`)}
+	}
+	p := &gbnfParser{tokens: gbnfTokenize(body)}
+	node := p.parseAlt()
+	m.parsed[name] = node
+	return node
+}
+
+// match returns every offset in input, starting at pos, where node could
+// stop matching (GBNF alternation/repetition makes this nondeterministic, so
+// callers need the full set, not just a greedy single match).
+func (m *gbnfMatcher) match(node gbnfNode, input string, pos int) []int {
+	switch n := node.(type) {
+	case gbnfLit:
+		if strings.HasPrefix(input[pos:], n.s) {
+			return []int{pos + len(n.s)}
+		}
+		return nil
+	case gbnfClass:
+		if pos >= len(input) {
+			return nil
+		}
+		if strings.IndexByte(n.chars, input[pos]) >= 0 {
+			return []int{pos + 1}
+		}
+		return nil
+	case gbnfRef:
+		return m.match(m.ruleNode(n.name), input, pos)
+	case gbnfOpt:
+		ends := map[int]bool{pos: true}
+		for _, e := range m.match(n.item, input, pos) {
+			ends[e] = true
+		}
+		return setToSlice(ends)
+	case gbnfStar:
+		return m.repeat(n.item, input, pos, 0)
+	case gbnfPlus:
+		return m.repeat(n.item, input, pos, 1)
+	case gbnfSeq:
+		positions := []int{pos}
+		for _, item := range n.items {
+			next := map[int]bool{}
+			for _, p := range positions {
+				for _, e := range m.match(item, input, p) {
+					next[e] = true
+				}
+			}
+			positions = setToSlice(next)
+			if len(positions) == 0 {
+				return nil
+			}
+		}
+		return positions
+	case gbnfAlt:
+		ends := map[int]bool{}
+		for _, item := range n.items {
+			for _, e := range m.match(item, input, pos) {
+				ends[e] = true
+			}
+		}
+		return setToSlice(ends)
+	default:
+		return nil
+	}
+}
+
+// repeat matches item zero-or-more (min=0) or one-or-more (min=1) times,
+// via a breadth-first closure over reachable offsets so it terminates even
+// when item can match the empty string.
+func (m *gbnfMatcher) repeat(item gbnfNode, input string, pos int, min int) []int {
+	reached := map[int]int{pos: 0} // offset -> repetitions so far
+	frontier := []int{pos}
+	for len(frontier) > 0 {
+		var next []int
+		for _, p := range frontier {
+			for _, e := range m.match(item, input, p) {
+				if e == p {
+					continue // empty match; already at this offset
+				}
+				if _, seen := reached[e]; !seen {
+					reached[e] = reached[p] + 1
+					next = append(next, e)
+				}
+			}
+		}
+		frontier = next
+	}
+	var ends []int
+	for offset, reps := range reached {
+		if reps >= min {
+			ends = append(ends, offset)
+		}
+	}
+	return ends
+}
+
+func setToSlice(s map[int]bool) []int {
+	out := make([]int, 0, len(s))
+	for k := range s {
+		out = append(out, k)
+	}
+	return out
+}
+
+type gbnfToken struct {
+	kind string // "lit", "class", "ident", "(", ")", "|", "?", "*", "+"
+	text string
+}
+
+func gbnfTokenize(body string) []gbnfToken {
+	var tokens []gbnfToken
+	i := 0
+	for i < len(body) {
+		c := body[i]
+		switch {
+		case c == ' ':
+			i++
+		case c == '"':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(body) && body[j] != '"' {
+				if body[j] == '\\' && j+1 < len(body) {
+					switch body[j+1] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(body[j+1])
+					}
+					j += 2
+					continue
+				}
+				sb.WriteByte(body[j])
+				j++
+			}
+			tokens = append(tokens, gbnfToken{kind: "lit", text: sb.String()})
+			i = j + 1
+		case c == '[':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(body) && body[j] != ']' {
+				if body[j] == '\\' && j+1 < len(body) {
+					switch body[j+1] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(body[j+1])
+					}
+					j += 2
+					continue
+				}
+				if j+1 < len(body) && body[j+1] == '-' && j+2 < len(body) && body[j+2] != ']' {
+					for r := body[j]; r <= body[j+2]; r++ {
+						sb.WriteByte(r)
+					}
+					j += 3
+					continue
+				}
+				sb.WriteByte(body[j])
+				j++
+			}
+			tokens = append(tokens, gbnfToken{kind: "class", text: sb.String()})
+			i = j + 1
+		case c == '(' || c == ')' || c == '|' || c == '?' || c == '*' || c == '+':
+			tokens = append(tokens, gbnfToken{kind: string(c)})
+			i++
+		default:
+			j := i
+			for j < len(body) && isGBNFIdentByte(body[j]) {
+				j++
+			}
+			if j == i {
+				i++ // skip anything unexpected rather than looping forever
+				continue
+			}
+			tokens = append(tokens, gbnfToken{kind: "ident", text: body[i:j]})
+			i = j
+		}
+	}
+	return tokens
+}
+
+func isGBNFIdentByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+type gbnfParser struct {
+	tokens []gbnfToken
+	pos    int
+}
+
+func (p *gbnfParser) peek() gbnfToken {
+	if p.pos >= len(p.tokens) {
+		return gbnfToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *gbnfParser) next() gbnfToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *gbnfParser) parseAlt() gbnfNode {
+	items := []gbnfNode{p.parseSeq()}
+	for p.peek().kind == "|" {
+		p.next()
+		items = append(items, p.parseSeq())
+	}
+	if len(items) == 1 {
+		return items[0]
+	}
+	return gbnfAlt{items: items}
+}
+
+func (p *gbnfParser) parseSeq() gbnfNode {
+	var items []gbnfNode
+	for {
+		switch p.peek().kind {
+		case "lit", "class", "ident", "(":
+			items = append(items, p.parseTerm())
+		default:
+			if len(items) == 1 {
+				return items[0]
+			}
+			return gbnfSeq{items: items}
+		}
+	}
+}
+
+func (p *gbnfParser) parseTerm() gbnfNode {
+	atom := p.parseAtom()
+	switch p.peek().kind {
+	case "?":
+		p.next()
+		return gbnfOpt{item: atom}
+	case "*":
+		p.next()
+		return gbnfStar{item: atom}
+	case "+":
+		p.next()
+		return gbnfPlus{item: atom}
+	default:
+		return atom
+	}
+}
+
+func (p *gbnfParser) parseAtom() gbnfNode {
+	t := p.next()
+	switch t.kind {
+	case "lit":
+		return gbnfLit{s: t.text}
+	case "class":
+		return gbnfClass{chars: t.text}
+	case "ident":
+		return gbnfRef{name: t.text}
+	case "(":
+		inner := p.parseAlt()
+		if p.peek().kind == ")" {
+			p.next()
+		}
+		return inner
+	default:
+		return gbnfLit{s: ""}
+	}
+}
@@ -0,0 +1,304 @@
+// Package grammar implements a constrained-decoding fallback for upstream
+// models that don't honor Gemini's `responseSchema`: a JSON Schema subset is
+// compiled to a GBNF-style grammar, and streamed text can be validated
+// against the original schema before being accepted as the final answer.
+package grammar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// SchemaToGBNF compiles the supported JSON Schema subset (type, enum,
+// oneOf/anyOf, required, properties, items, minItems) into a GBNF grammar
+// string rooted at a `root` rule, suitable for a grammar-constrained sampler.
+// A string `pattern` is not expressed in the grammar: such a string compiles
+// to the unconstrained `string` rule, and the pattern is checked only by
+// ValidateAgainstSchema.
+func SchemaToGBNF(schema []byte) (string, error) {
+	if !gjson.ValidBytes(schema) {
+		return "", fmt.Errorf("grammar: schema is not valid JSON")
+	}
+
+	c := &compiler{rules: map[string]string{}}
+	rootRef, err := c.compile(gjson.ParseBytes(schema), "root")
+	if err != nil {
+		return "", err
+	}
+	if body, ok := c.rules[rootRef]; ok {
+		// rootRef is a rule we generated; promote its body to "root" and drop
+		// the now-unused alias so the grammar doesn't define it twice.
+		c.rules["root"] = body
+		if rootRef != "root" {
+			delete(c.rules, rootRef)
+		}
+	} else {
+		// rootRef is a shared primitive (or a quoted literal like "null");
+		// root simply references it by name.
+		c.rules["root"] = rootRef
+	}
+
+	names := make([]string, 0, len(c.rules))
+	for name := range c.rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// root must come first for readability, the rest in a stable order.
+	ordered := make([]string, 0, len(names))
+	ordered = append(ordered, "root")
+	for _, name := range names {
+		if name != "root" {
+			ordered = append(ordered, name)
+		}
+	}
+
+	var b strings.Builder
+	for _, name := range ordered {
+		fmt.Fprintf(&b, "%s ::= %s\n", name, c.rules[name])
+	}
+	b.WriteString(jsonPrimitives)
+	return b.String(), nil
+}
+
+// jsonPrimitives are the leaf rules every compiled grammar shares.
+const jsonPrimitives = `ws ::= [ \t\n]*
+string ::= "\"" ([^"\\] | "\\" .)* "\""
+number ::= "-"? [0-9]+ ("." [0-9]+)?
+integer ::= "-"? [0-9]+
+boolean ::= "true" | "false"
+`
+
+type compiler struct {
+	rules map[string]string
+	seq   int
+}
+
+func (c *compiler) newRuleName(hint string) string {
+	c.seq++
+	return fmt.Sprintf("%s_%d", hint, c.seq)
+}
+
+// compile returns the name of a rule (existing primitive or newly defined)
+// representing schema, defined under ruleHint if a new rule is needed.
+func (c *compiler) compile(schema gjson.Result, ruleHint string) (string, error) {
+	if alt := schema.Get("oneOf"); alt.Exists() {
+		return c.compileAlternatives(alt, ruleHint)
+	}
+	if alt := schema.Get("anyOf"); alt.Exists() {
+		return c.compileAlternatives(alt, ruleHint)
+	}
+	if enum := schema.Get("enum"); enum.Exists() {
+		return c.compileEnum(enum, ruleHint)
+	}
+
+	switch schema.Get("type").String() {
+	case "object":
+		return c.compileObject(schema, ruleHint)
+	case "array":
+		return c.compileArray(schema, ruleHint)
+	case "string":
+		if pattern := schema.Get("pattern"); pattern.Exists() {
+			// GBNF has no native regex support; fall back to an unconstrained
+			// string and rely on ValidateAgainstSchema to enforce the pattern.
+			return "string", nil
+		}
+		return "string", nil
+	case "number":
+		return "number", nil
+	case "integer":
+		return "integer", nil
+	case "boolean":
+		return "boolean", nil
+	case "null":
+		return `"null"`, nil
+	default:
+		return "", fmt.Errorf("grammar: unsupported schema type %q", schema.Get("type").String())
+	}
+}
+
+func (c *compiler) compileAlternatives(alt gjson.Result, ruleHint string) (string, error) {
+	var options []string
+	var err error
+	alt.ForEach(func(_, sub gjson.Result) bool {
+		var ref string
+		ref, err = c.compile(sub, ruleHint)
+		if err != nil {
+			return false
+		}
+		options = append(options, ref)
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	name := c.newRuleName(ruleHint)
+	c.rules[name] = strings.Join(options, " | ")
+	return name, nil
+}
+
+func (c *compiler) compileEnum(enum gjson.Result, ruleHint string) (string, error) {
+	var options []string
+	enum.ForEach(func(_, v gjson.Result) bool {
+		options = append(options, fmt.Sprintf("%q", v.Raw))
+		return true
+	})
+	name := c.newRuleName(ruleHint)
+	c.rules[name] = strings.Join(options, " | ")
+	return name, nil
+}
+
+func (c *compiler) compileObject(schema gjson.Result, ruleHint string) (string, error) {
+	props := schema.Get("properties")
+	required := map[string]bool{}
+	schema.Get("required").ForEach(func(_, v gjson.Result) bool {
+		required[v.String()] = true
+		return true
+	})
+
+	var keys []string
+	props.ForEach(func(k, _ gjson.Result) bool {
+		keys = append(keys, k.String())
+		return true
+	})
+	sort.Strings(keys)
+
+	fieldRule := func(key string) (string, error) {
+		valueRef, err := c.compile(props.Get(gjson.Escape(key)), ruleHint+"_"+key)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("\"\\\"%s\\\":\" ws %s", key, valueRef), nil
+	}
+
+	var requiredFields, optionalKeys []string
+	for _, key := range keys {
+		if required[key] {
+			field, err := fieldRule(key)
+			if err != nil {
+				return "", err
+			}
+			requiredFields = append(requiredFields, field)
+		} else {
+			optionalKeys = append(optionalKeys, key)
+		}
+	}
+
+	// optionalTail expands to every valid comma-separated arrangement of the
+	// optional properties actually present, in sorted key order (including the
+	// empty arrangement). Whether an arrangement needs a leading comma
+	// depends only on whether a required field already came before it, not
+	// on which optional fields were chosen.
+	optionalTail, err := c.compileOptionalTail(optionalKeys, fieldRule, ruleHint, len(requiredFields) > 0)
+	if err != nil {
+		return "", err
+	}
+
+	var fields []string
+	fields = append(fields, requiredFields...)
+	body := `"{" ws "}"`
+	switch {
+	case len(fields) == 0 && optionalTail == "":
+		// no properties at all
+	case len(fields) == 0:
+		body = `"{" ws ` + optionalTail + ` ws "}"`
+	default:
+		body = `"{" ws ` + strings.Join(fields, ` ws "," ws `) + ` ` + optionalTail + ` ws "}"`
+	}
+
+	name := c.newRuleName(ruleHint)
+	c.rules[name] = body
+	return name, nil
+}
+
+// maxExactOptionalProperties bounds compileOptionalTail's subset enumeration.
+// Above this many optional properties, the 2^n alternative count stops being
+// a reasonable grammar size, so SchemaToGBNF reports an error rather than
+// silently producing a multi-million-line grammar.
+const maxExactOptionalProperties = 12
+
+// compileOptionalTail builds the grammar for every valid arrangement of a
+// subset (including the empty subset) of the given optional property keys,
+// in the order given (sorted by compileObject), with correct comma placement
+// between the fields actually chosen. It returns the name of the rule to splice into the enclosing
+// object body, or "" if there are no optional keys.
+//
+// A subset can't be expressed by independently wrapping each field in
+// `(...)?.` and joining with a fixed comma: whether a given field needs a
+// leading comma depends on whether any *other* optional field before it was
+// also chosen, which an independent per-field toggle can't encode. Instead
+// every one of the 2^n subsets is enumerated explicitly, each rendered with
+// its own, definitely-correct comma placement; leadingComma controls whether
+// a non-empty subset needs a comma before its first field (true whenever a
+// required field already precedes this tail).
+func (c *compiler) compileOptionalTail(keys []string, fieldRule func(string) (string, error), ruleHint string, leadingComma bool) (string, error) {
+	if len(keys) == 0 {
+		return "", nil
+	}
+	if len(keys) > maxExactOptionalProperties {
+		return "", fmt.Errorf("grammar: %d optional properties exceeds the %d-property limit for exact subset enumeration", len(keys), maxExactOptionalProperties)
+	}
+
+	n := len(keys)
+	fieldRefs := make([]string, n)
+	for i, key := range keys {
+		ref, err := fieldRule(key)
+		if err != nil {
+			return "", err
+		}
+		fieldRefs[i] = ref
+	}
+
+	alternatives := make([]string, 0, 1<<n)
+	for mask := 0; mask < (1 << n); mask++ {
+		var chosen []string
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 {
+				chosen = append(chosen, fmt.Sprintf("(%s)", fieldRefs[i]))
+			}
+		}
+		if len(chosen) == 0 {
+			alternatives = append(alternatives, `""`)
+			continue
+		}
+		arrangement := strings.Join(chosen, ` ws "," ws `)
+		if leadingComma {
+			arrangement = `"," ws ` + arrangement
+		}
+		alternatives = append(alternatives, "("+arrangement+")")
+	}
+
+	name := c.newRuleName(ruleHint + "_opt")
+	c.rules[name] = strings.Join(alternatives, " | ")
+	return name, nil
+}
+
+func (c *compiler) compileArray(schema gjson.Result, ruleHint string) (string, error) {
+	itemRef := "string"
+	if items := schema.Get("items"); items.Exists() {
+		var err error
+		itemRef, err = c.compile(items, ruleHint+"_item")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	minItems := int(schema.Get("minItems").Int())
+	var body string
+	switch {
+	case minItems <= 0:
+		body = fmt.Sprintf(`"[" ws "]" | "[" ws %s (ws "," ws %s)* ws "]"`, itemRef, itemRef)
+	default:
+		repeat := make([]string, minItems)
+		for i := range repeat {
+			repeat[i] = itemRef
+		}
+		body = fmt.Sprintf(`"[" ws %s (ws "," ws %s)* ws "]"`, strings.Join(repeat, ` ws "," ws `), itemRef)
+	}
+
+	name := c.newRuleName(ruleHint)
+	c.rules[name] = body
+	return name, nil
+}
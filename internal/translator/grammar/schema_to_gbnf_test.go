@@ -0,0 +1,179 @@
+package grammar
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSchemaToGBNF_Object(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"age": {"type": "integer"}
+		},
+		"required": ["name"]
+	}`)
+
+	out, err := SchemaToGBNF(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "root ::=") {
+		t.Error("expected a root rule")
+	}
+	if !strings.Contains(out, `"\"name\":"`) {
+		t.Error("expected the name property to appear in the grammar")
+	}
+}
+
+func TestSchemaToGBNF_Object_OptionalFieldsAllowAnySubset(t *testing.T) {
+	// A schema with two optional fields and no required fields must not
+	// hardcode a comma between them: a grammar that always joins fields with
+	// `ws "," ws` rejects documents that include only the second optional
+	// field, since the first is omitted along with its comma. gbnfAccepts
+	// actually parses the generated grammar and matches candidate documents
+	// against it, rather than just checking rule names appear in the text.
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"a": {"type": "boolean"},
+			"b": {"type": "boolean"}
+		}
+	}`)
+
+	out, err := SchemaToGBNF(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, doc := range []string{`{}`, `{"a":true}`, `{"b":false}`, `{"a":true,"b":false}`} {
+		if !gbnfAccepts(out, doc) {
+			t.Errorf("expected grammar to accept %s, got grammar:\n%s", doc, out)
+		}
+	}
+	if gbnfAccepts(out, `{"a":true,,"b":false}`) {
+		t.Error("expected grammar to reject a document with a dangling comma")
+	}
+}
+
+func TestSchemaToGBNF_Object_OptionalFieldSkippedAfterRequiredField(t *testing.T) {
+	// With a required field preceding two optional ones, omitting the first
+	// optional field while keeping the second must still produce a valid
+	// (correctly comma-placed) document.
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"req": {"type": "boolean"},
+			"a": {"type": "boolean"},
+			"b": {"type": "boolean"}
+		},
+		"required": ["req"]
+	}`)
+
+	out, err := SchemaToGBNF(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, doc := range []string{`{"req":true}`, `{"req":true,"a":false}`, `{"req":true,"b":false}`, `{"req":true,"a":false,"b":true}`} {
+		if !gbnfAccepts(out, doc) {
+			t.Errorf("expected grammar to accept %s, got grammar:\n%s", doc, out)
+		}
+	}
+}
+
+func TestSchemaToGBNF_Object_MiddleOptionalFieldCanBeSkipped(t *testing.T) {
+	// Three optional fields a, b, c: including a and c while skipping the
+	// middle field b must produce a valid document. A grammar that only
+	// allows contiguous prefixes of the optional sequence (the bug a prior
+	// fix introduced) cannot accept this.
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"a": {"type": "boolean"},
+			"b": {"type": "boolean"},
+			"c": {"type": "boolean"}
+		}
+	}`)
+
+	out, err := SchemaToGBNF(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !gbnfAccepts(out, `{"a":true,"c":false}`) {
+		t.Errorf("expected grammar to accept a document skipping the middle optional field, got grammar:\n%s", out)
+	}
+}
+
+func TestSchemaToGBNF_Enum(t *testing.T) {
+	schema := []byte(`{"enum": ["a", "b", "c"]}`)
+
+	out, err := SchemaToGBNF(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, doc := range []string{`"a"`, `"b"`, `"c"`} {
+		if !gbnfAccepts(out, doc) {
+			t.Errorf("expected grammar to accept enum value %s, got grammar:\n%s", doc, out)
+		}
+	}
+	if gbnfAccepts(out, `"d"`) {
+		t.Error("expected grammar to reject a value outside the enum")
+	}
+}
+
+func TestValidateAgainstSchema(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"properties": {"name": {"type": "string"}},
+		"required": ["name"]
+	}`)
+
+	if err := ValidateAgainstSchema([]byte(`{"name":"ada"}`), schema); err != nil {
+		t.Errorf("expected valid document to pass, got: %v", err)
+	}
+	if err := ValidateAgainstSchema([]byte(`{}`), schema); err == nil {
+		t.Error("expected missing required property to fail validation")
+	}
+	if err := ValidateAgainstSchema([]byte(`not json`), schema); err == nil {
+		t.Error("expected invalid JSON to fail validation")
+	}
+}
+
+func TestEnforcer_EnsureJSON_RepairsInvalidOutput(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}`)
+	enforcer := NewEnforcer(schema)
+
+	attempts := 0
+	regenerate := func(_ context.Context, _ string) (string, error) {
+		attempts++
+		return `{"name":"ada"}`, nil
+	}
+
+	out, err := enforcer.EnsureJSON(context.Background(), `not json`, regenerate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != `{"name":"ada"}` {
+		t.Errorf("expected repaired JSON to be returned, got %q", out)
+	}
+	if attempts != 1 {
+		t.Errorf("expected exactly 1 repair attempt, got %d", attempts)
+	}
+}
+
+func TestEnforcer_EnsureJSON_GivesUpAfterMaxAttempts(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}`)
+	enforcer := &Enforcer{Schema: schema, MaxRepairAttempts: 1}
+
+	regenerate := func(_ context.Context, _ string) (string, error) {
+		return `still not json`, nil
+	}
+
+	if _, err := enforcer.EnsureJSON(context.Background(), `not json`, regenerate); err == nil {
+		t.Error("expected an error once repair attempts are exhausted")
+	}
+}
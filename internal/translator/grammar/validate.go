@@ -0,0 +1,162 @@
+package grammar
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+
+	"github.com/tidwall/gjson"
+)
+
+// ValidateAgainstSchema checks data against the same JSON Schema subset
+// SchemaToGBNF compiles (type, enum, oneOf/anyOf, required, properties,
+// items, minItems, string pattern). It returns a descriptive error on the
+// first mismatch found, suitable for embedding in a repair prompt.
+func ValidateAgainstSchema(data []byte, schema []byte) error {
+	if !gjson.ValidBytes(data) {
+		return fmt.Errorf("grammar: response is not valid JSON")
+	}
+	return validate(gjson.ParseBytes(data), gjson.ParseBytes(schema), "$")
+}
+
+func validate(value, schema gjson.Result, path string) error {
+	if alt := schema.Get("oneOf"); alt.Exists() {
+		return validateAlternatives(value, alt, path)
+	}
+	if alt := schema.Get("anyOf"); alt.Exists() {
+		return validateAlternatives(value, alt, path)
+	}
+	if enum := schema.Get("enum"); enum.Exists() {
+		return validateEnum(value, enum, path)
+	}
+
+	switch schema.Get("type").String() {
+	case "object":
+		return validateObject(value, schema, path)
+	case "array":
+		return validateArray(value, schema, path)
+	case "string":
+		if value.Type != gjson.String {
+			return fmt.Errorf("grammar: %s: expected string", path)
+		}
+		if pattern := schema.Get("pattern"); pattern.Exists() {
+			re, err := regexp.Compile(pattern.String())
+			if err != nil {
+				return fmt.Errorf("grammar: %s: invalid pattern %q: %w", path, pattern.String(), err)
+			}
+			if !re.MatchString(value.String()) {
+				return fmt.Errorf("grammar: %s: value does not match pattern %q", path, pattern.String())
+			}
+		}
+		return nil
+	case "number":
+		if value.Type != gjson.Number {
+			return fmt.Errorf("grammar: %s: expected number", path)
+		}
+		return nil
+	case "integer":
+		if value.Type != gjson.Number || value.Num != math.Trunc(value.Num) {
+			return fmt.Errorf("grammar: %s: expected integer", path)
+		}
+		return nil
+	case "boolean":
+		if value.Type != gjson.True && value.Type != gjson.False {
+			return fmt.Errorf("grammar: %s: expected boolean", path)
+		}
+		return nil
+	case "null":
+		if value.Type != gjson.Null {
+			return fmt.Errorf("grammar: %s: expected null", path)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func validateAlternatives(value, alt gjson.Result, path string) error {
+	var lastErr error
+	matched := false
+	alt.ForEach(func(_, sub gjson.Result) bool {
+		if err := validate(value, sub, path); err == nil {
+			matched = true
+			return false
+		} else {
+			lastErr = err
+		}
+		return true
+	})
+	if matched {
+		return nil
+	}
+	return fmt.Errorf("grammar: %s: matched none of the allowed schemas (%w)", path, lastErr)
+}
+
+func validateEnum(value, enum gjson.Result, path string) error {
+	match := false
+	enum.ForEach(func(_, v gjson.Result) bool {
+		if v.Raw == value.Raw {
+			match = true
+			return false
+		}
+		return true
+	})
+	if !match {
+		return fmt.Errorf("grammar: %s: value %s is not one of the allowed enum values", path, value.Raw)
+	}
+	return nil
+}
+
+func validateObject(value, schema gjson.Result, path string) error {
+	if !value.IsObject() {
+		return fmt.Errorf("grammar: %s: expected object", path)
+	}
+
+	var missing error
+	schema.Get("required").ForEach(func(_, name gjson.Result) bool {
+		if !value.Get(name.String()).Exists() {
+			missing = fmt.Errorf("grammar: %s: missing required property %q", path, name.String())
+			return false
+		}
+		return true
+	})
+	if missing != nil {
+		return missing
+	}
+
+	var propErr error
+	schema.Get("properties").ForEach(func(key, propSchema gjson.Result) bool {
+		field := value.Get(key.String())
+		if !field.Exists() {
+			return true
+		}
+		if err := validate(field, propSchema, path+"."+key.String()); err != nil {
+			propErr = err
+			return false
+		}
+		return true
+	})
+	return propErr
+}
+
+func validateArray(value, schema gjson.Result, path string) error {
+	if !value.IsArray() {
+		return fmt.Errorf("grammar: %s: expected array", path)
+	}
+
+	items := value.Array()
+	if minItems := schema.Get("minItems"); minItems.Exists() && int64(len(items)) < minItems.Int() {
+		return fmt.Errorf("grammar: %s: expected at least %d items, got %d", path, minItems.Int(), len(items))
+	}
+
+	itemSchema := schema.Get("items")
+	if !itemSchema.Exists() {
+		return nil
+	}
+	for i, item := range items {
+		if err := validate(item, itemSchema, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}